@@ -12403,11 +12403,19 @@ const docTemplate = `{
                 "field"
             ],
             "properties": {
+                "code": {
+                    "description": "Code is a machine-readable reason for the error, such as \"required\" or\n\"invalid_type\". It's usually the validation tag that failed.",
+                    "type": "string"
+                },
                 "detail": {
                     "type": "string"
                 },
                 "field": {
                     "type": "string"
+                },
+                "id": {
+                    "description": "ID is a stable identifier for the error, such as \"username.taken\", set\nfrom the errid tag of the field. Unlike Code, it doesn't depend on how\nthe field is validated.",
+                    "type": "string"
                 }
             }
         },
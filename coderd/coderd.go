@@ -788,6 +788,7 @@ func New(options *Options) *API {
 		api.APIHandler = r
 
 		r.NotFound(func(rw http.ResponseWriter, r *http.Request) { httpapi.RouteNotFound(rw) })
+		r.MethodNotAllowed(httpapi.MethodNotAllowed)
 		r.Use(
 			// Specific routes can specify different limits, but every rate
 			// limit must be configurable by the admin.
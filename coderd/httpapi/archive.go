@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"mime"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// WriteTarGz streams a gzipped tar archive as a download named filename with a
+// 200. files is called to add entries to the archive, which is written as it
+// goes rather than built in memory.
+//
+// The headers are sent before files is called, so an error can't change the
+// status. Instead, the archive is left unterminated, so clients fail to
+// extract it rather than silently getting part of it, and the error is
+// returned for the handler to log.
+func WriteTarGz(rw http.ResponseWriter, filename string, files func(tw *tar.Writer) error) error {
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(http.StatusOK)
+
+	gw := gzip.NewWriter(rw)
+	tw := tar.NewWriter(gw)
+	err := files(tw)
+	if err != nil {
+		// Send what was written so far without the end of archive markers.
+		_ = gw.Flush()
+		return xerrors.Errorf("write archive entries: %w", err)
+	}
+	err = tw.Close()
+	if err != nil {
+		return xerrors.Errorf("close tar: %w", err)
+	}
+	err = gw.Close()
+	if err != nil {
+		return xerrors.Errorf("close gzip: %w", err)
+	}
+	return nil
+}
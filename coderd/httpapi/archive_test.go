@@ -0,0 +1,99 @@
+package httpapi_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestWriteTarGz(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"main.tf":          `resource "null_resource" "dev" {}`,
+		"scripts/setup.sh": "#!/bin/sh\necho hello\n",
+	}
+	addFile := func(tw *tar.Writer, name string) error {
+		err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o644,
+			Size: int64(len(files[name])),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write([]byte(files[name]))
+		return err
+	}
+	extract := func(r io.Reader) (map[string]string, error) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		extracted := map[string]string{}
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if xerrors.Is(err, io.EOF) {
+				return extracted, nil
+			}
+			if err != nil {
+				return extracted, err
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return extracted, err
+			}
+			extracted[hdr.Name] = string(data)
+		}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		err := httpapi.WriteTarGz(rw, "template source.tar.gz", func(tw *tar.Writer) error {
+			if err := addFile(tw, "main.tf"); err != nil {
+				return err
+			}
+			return addFile(tw, "scripts/setup.sh")
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "application/gzip", rw.Header().Get("Content-Type"))
+
+		disposition, params, err := mime.ParseMediaType(rw.Header().Get("Content-Disposition"))
+		require.NoError(t, err)
+		require.Equal(t, "attachment", disposition)
+		require.Equal(t, "template source.tar.gz", params["filename"])
+
+		extracted, err := extract(rw.Body)
+		require.NoError(t, err)
+		require.Equal(t, files, extracted)
+	})
+
+	t.Run("CallbackError", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		err := httpapi.WriteTarGz(rw, "logs.tar.gz", func(tw *tar.Writer) error {
+			if err := addFile(tw, "main.tf"); err != nil {
+				return err
+			}
+			return xerrors.New("database went away")
+		})
+		require.ErrorContains(t, err, "database went away")
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		// The partial archive must not extract cleanly.
+		_, err = extract(rw.Body)
+		require.Error(t, err)
+	})
+}
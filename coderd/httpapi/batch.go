@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// BatchResult is the outcome of a single item in a batch request.
+type BatchResult struct {
+	// Index is the position of the item in the request.
+	Index int `json:"index"`
+	// Status is the HTTP status the item would have had as its own request.
+	Status int `json:"status"`
+	// Error is set if the item failed.
+	Error *codersdk.Response `json:"error,omitempty"`
+}
+
+// BatchResponse is the body written by WriteBatchResult.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// WriteBatchResult reports per-item outcomes of a batch request. If every item
+// succeeded a 200 is written, otherwise a 207 Multi-Status so clients know to
+// inspect each result.
+func WriteBatchResult(ctx context.Context, rw http.ResponseWriter, results []BatchResult) {
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Status < 200 || result.Status >= 300 {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+	if results == nil {
+		results = []BatchResult{}
+	}
+	Write(ctx, rw, status, BatchResponse{Results: results})
+}
@@ -0,0 +1,60 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWriteBatchResult(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AllSuccess", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteBatchResult(context.Background(), rw, []httpapi.BatchResult{
+			{Index: 0, Status: http.StatusOK},
+			{Index: 1, Status: http.StatusOK},
+		})
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp httpapi.BatchResponse
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Results, 2)
+		for _, result := range resp.Results {
+			require.Equal(t, http.StatusOK, result.Status)
+			require.Nil(t, result.Error)
+		}
+	})
+
+	t.Run("Mixed", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteBatchResult(context.Background(), rw, []httpapi.BatchResult{
+			{Index: 0, Status: http.StatusCreated},
+			{Index: 1, Status: http.StatusBadRequest, Error: &codersdk.Response{
+				Message:     "Validation failed.",
+				Validations: []codersdk.ValidationError{{Field: "name", Detail: "required"}},
+			}},
+			{Index: 2, Status: http.StatusNotFound, Error: &httpapi.ResourceNotFoundResponse},
+		})
+		require.Equal(t, http.StatusMultiStatus, rw.Code)
+
+		var resp httpapi.BatchResponse
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Results, 3)
+		require.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		require.Nil(t, resp.Results[0].Error)
+		require.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+		require.Equal(t, "name", resp.Results[1].Error.Validations[0].Field)
+		require.Equal(t, 2, resp.Results[2].Index)
+		require.Equal(t, httpapi.ResourceNotFoundResponse.Message, resp.Results[2].Error.Message)
+	})
+}
@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+var (
+	// ErrBearerTokenMissing is returned by BearerToken when the request has no
+	// Bearer credentials.
+	ErrBearerTokenMissing = xerrors.New("bearer token missing")
+	// ErrBearerTokenMalformed is returned by BearerToken when the Bearer
+	// credentials can't be parsed.
+	ErrBearerTokenMalformed = xerrors.New("bearer token malformed")
+)
+
+// BearerToken returns the token from the request's "Authorization: Bearer"
+// header. The scheme is matched case-insensitively and surrounding whitespace
+// is ignored. Credentials for other schemes, in other Authorization headers
+// or comma separated in the same one, are skipped. Errors wrap
+// ErrBearerTokenMissing or ErrBearerTokenMalformed.
+func BearerToken(r *http.Request) (string, error) {
+	var token string
+	for _, header := range r.Header.Values("Authorization") {
+		for _, credentials := range strings.Split(header, ",") {
+			scheme, value, _ := strings.Cut(strings.TrimSpace(credentials), " ")
+			if !strings.EqualFold(scheme, "bearer") {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return "", xerrors.Errorf("%w: no token after the Bearer scheme", ErrBearerTokenMalformed)
+			}
+			if strings.ContainsAny(value, " \t") {
+				return "", xerrors.Errorf("%w: token must not contain whitespace", ErrBearerTokenMalformed)
+			}
+			if token != "" && token != value {
+				return "", xerrors.Errorf("%w: multiple different Bearer tokens provided", ErrBearerTokenMalformed)
+			}
+			token = value
+		}
+	}
+	if token == "" {
+		return "", ErrBearerTokenMissing
+	}
+	return token, nil
+}
+
+// RequireBearer is like BearerToken, but writes a 401 with a
+// WWW-Authenticate challenge and returns false if there is no valid token.
+func RequireBearer(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	token, err := BearerToken(r)
+	if err != nil {
+		challenge := `Bearer`
+		if xerrors.Is(err, ErrBearerTokenMalformed) {
+			challenge = `Bearer error="invalid_request"`
+		}
+		rw.Header().Set("WWW-Authenticate", challenge)
+		Write(r.Context(), rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Authorization header must contain a valid Bearer token.",
+			Detail:  err.Error(),
+		})
+		return "", false
+	}
+	return token, true
+}
@@ -0,0 +1,79 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestBearerToken(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		headers []string
+		token   string
+		err     error
+	}{
+		{name: "Valid", headers: []string{"Bearer abc.def"}, token: "abc.def"},
+		{name: "Lowercase", headers: []string{"bearer abc.def"}, token: "abc.def"},
+		{name: "ExtraWhitespace", headers: []string{"  Bearer    abc.def  "}, token: "abc.def"},
+		{name: "MultipleSchemes", headers: []string{"Basic dXNlcjpwYXNz, Bearer abc.def"}, token: "abc.def"},
+		{name: "MultipleHeaders", headers: []string{"Basic dXNlcjpwYXNz", "Bearer abc.def"}, token: "abc.def"},
+		{name: "Missing", err: httpapi.ErrBearerTokenMissing},
+		{name: "OtherScheme", headers: []string{"Basic dXNlcjpwYXNz"}, err: httpapi.ErrBearerTokenMissing},
+		{name: "NoToken", headers: []string{"Bearer "}, err: httpapi.ErrBearerTokenMalformed},
+		{name: "SpaceInToken", headers: []string{"Bearer abc def"}, err: httpapi.ErrBearerTokenMalformed},
+		{name: "Conflicting", headers: []string{"Bearer abc", "Bearer def"}, err: httpapi.ErrBearerTokenMalformed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/", nil)
+			for _, header := range tc.headers {
+				r.Header.Add("Authorization", header)
+			}
+			token, err := httpapi.BearerToken(r)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.token, token)
+		})
+	}
+}
+
+func TestRequireBearer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer abc.def")
+		token, ok := httpapi.RequireBearer(rw, r)
+		require.True(t, ok)
+		require.Equal(t, "abc.def", token)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer")
+		_, ok := httpapi.RequireBearer(rw, r)
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnauthorized, rw.Code)
+		require.Equal(t, `Bearer error="invalid_request"`, rw.Header().Get("WWW-Authenticate"))
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Contains(t, resp.Detail, "malformed")
+	})
+}
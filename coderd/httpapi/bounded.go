@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// Bounds limits the shape of a request body. A zero value for any field
+// means that dimension is unbounded.
+type Bounds struct {
+	// MaxDepth is the deepest nesting of objects and arrays.
+	MaxDepth int
+	// MaxArrayLength is the most elements in any single array.
+	MaxArrayLength int
+	// MaxKeys is the most object keys in the whole body.
+	MaxKeys int
+}
+
+// ReadBounded is like Read, but first scans the body and rejects it with a
+// 400 if it exceeds limits. The scan happens before anything is unmarshaled,
+// so oversized payloads are never allocated into value, and it stops reading
+// at the first bound exceeded.
+func ReadBounded(rw http.ResponseWriter, r *http.Request, value interface{}, limits Bounds) bool {
+	ctx := r.Context()
+	if r.Body == nil {
+		return Read(ctx, rw, r, value)
+	}
+
+	// The scanned part of the body is kept to be decoded afterwards.
+	var scanned bytes.Buffer
+	body := http.MaxBytesReader(rw, r.Body, maxRawBodySize)
+	exceeded, err := checkBounds(skipBOM(io.TeeReader(body, &scanned)), limits)
+	if exceeded != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body exceeds limits.",
+			Detail:  exceeded.Error(),
+		})
+		return false
+	}
+	if writeBodyTooLarge(ctx, rw, err) {
+		return false
+	}
+	// Invalid JSON is left for Read to report, with whatever wasn't scanned.
+	r.Body = io.NopCloser(io.MultiReader(&scanned, body))
+	return Read(ctx, rw, r, value)
+}
+
+// checkBounds returns an error naming the first bound the JSON in data
+// exceeds as exceeded, or err if data can't be read or isn't valid JSON.
+func checkBounds(data io.Reader, limits Bounds) (exceeded error, err error) {
+	dec := json.NewDecoder(data)
+	// Each open value tracks whether it's an array, its element count, and
+	// whether the next token in an object is a key.
+	type frame struct {
+		array     bool
+		length    int
+		expectKey bool
+	}
+	var (
+		stack []*frame
+		keys  int
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		delim, isDelim := tok.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && !stack[len(stack)-1].array {
+				stack[len(stack)-1].expectKey = true
+			}
+			continue
+		}
+		if top != nil && !top.array && top.expectKey {
+			keys++
+			if limits.MaxKeys > 0 && keys > limits.MaxKeys {
+				return xerrors.Errorf("max keys exceeded: the body must contain at most %d object keys", limits.MaxKeys), nil
+			}
+			top.expectKey = false
+			continue
+		}
+		if top != nil && top.array {
+			top.length++
+			if limits.MaxArrayLength > 0 && top.length > limits.MaxArrayLength {
+				return xerrors.Errorf("max array length exceeded: arrays must contain at most %d elements", limits.MaxArrayLength), nil
+			}
+		}
+		if isDelim {
+			stack = append(stack, &frame{array: delim == '[', expectKey: delim == '{'})
+			if limits.MaxDepth > 0 && len(stack) > limits.MaxDepth {
+				return xerrors.Errorf("max depth exceeded: objects and arrays must be nested at most %d deep", limits.MaxDepth), nil
+			}
+			continue
+		}
+		if top != nil && !top.array {
+			top.expectKey = true
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadBounded(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	limits := httpapi.Bounds{MaxDepth: 4, MaxArrayLength: 3, MaxKeys: 6}
+
+	for _, tc := range []struct {
+		name   string
+		body   string
+		detail string
+	}{
+		{name: "WithinBounds", body: `{"items":[{"a":1,"b":[1,2,3]},{"c":{}}]}`},
+		{name: "Depth", body: `{"items":[{"a":{"b":{}}}]}`, detail: "max depth"},
+		{name: "ArrayLength", body: `{"items":[{},{},{},{}]}`, detail: "max array length"},
+		{name: "NestedArrayLength", body: `{"items":[{"a":[1,2,3,4]}]}`, detail: "max array length"},
+		{name: "Keys", body: `{"items":[{"a":1,"b":2,"c":3},{"d":4,"e":5,"f":6}]}`, detail: "max keys"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString(tc.body))
+			var v request
+			ok := httpapi.ReadBounded(rw, r, &v, limits)
+			if tc.detail == "" {
+				require.True(t, ok)
+				require.Len(t, v.Items, 2)
+				return
+			}
+			require.False(t, ok)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			require.Empty(t, v.Items)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Contains(t, resp.Detail, tc.detail)
+		})
+	}
+
+	t.Run("StopsReading", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		// Reading past the exceeded bound would fail.
+		body := io.MultiReader(strings.NewReader(`{"items":[{},{},{},{}`), iotest.ErrReader(io.ErrUnexpectedEOF))
+		r := httptest.NewRequest("POST", "/", body)
+		require.False(t, httpapi.ReadBounded(rw, r, &request{}, limits))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Contains(t, resp.Detail, "max array length")
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"items":[{}`))
+		require.False(t, httpapi.ReadBounded(rw, r, &request{}, limits))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "Request body must be valid JSON.", resp.Message)
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"items":[{"a":{"b":{"c":[1,2,3,4,5]}}}]}`))
+		var v request
+		require.True(t, httpapi.ReadBounded(rw, r, &v, httpapi.Bounds{}))
+	})
+}
@@ -0,0 +1,229 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ReadCaseInsensitiveKeys is like Read, but accepts object keys in any casing
+// or underscore style. Incoming keys such as "createdAt" or "CreatedAt" are
+// rewritten to the canonical json tag of the matching struct field
+// ("created_at") before decoding. This exists to give clients a transition
+// window when field names change style.
+//
+// If both the canonical key and an alias are present, the canonical key wins
+// and a Warning header describing the dropped alias is added to the response.
+// Two aliases of a field without its canonical key are ambiguous, so they're
+// rejected with a 400 validation error.
+func ReadCaseInsensitiveKeys(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	var raw json.RawMessage
+	err := json.NewDecoder(skipBOM(r.Body)).Decode(&raw)
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	normalized, warnings, err := normalizeKeys(raw, reflect.TypeOf(value), "")
+	var conflict *keyConflictError
+	if errors.As(err, &conflict) {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body has conflicting spellings of a field.",
+			Detail:  err.Error(),
+			Validations: []codersdk.ValidationError{{
+				Field:  conflict.field,
+				Detail: fmt.Sprintf("is set by both %q and %q", conflict.keys[0], conflict.keys[1]),
+				Code:   "duplicate_field",
+			}},
+		})
+		return false
+	}
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	for _, warning := range warnings {
+		rw.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+
+	err = json.Unmarshal(normalized, value)
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	return validateRequest(ctx, rw, r, value)
+}
+
+// normalizeKey folds a key so that snake_case, camelCase and PascalCase
+// spellings of the same name compare equal.
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// jsonField is a struct field as seen by encoding/json.
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields returns typ's fields keyed by the normalized form of their json
+// names. Embedded structs are flattened like encoding/json does.
+func jsonFields(typ reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[normalizeKey(name)] = jsonField{name: name, typ: field.Type}
+	}
+	return fields
+}
+
+// keyConflictError is returned by normalizeKeys when two aliases of a field
+// are present without its canonical key, so neither is obviously meant.
+type keyConflictError struct {
+	field string
+	keys  [2]string
+}
+
+func (e *keyConflictError) Error() string {
+	return fmt.Sprintf("%q and %q are both spellings of %q", e.keys[0], e.keys[1], e.field)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// normalizeKeys rewrites the object keys in raw to match the json tags of
+// typ, recursing into nested structs, slices and maps.
+func normalizeKeys(raw json.RawMessage, typ reflect.Type, path string) (json.RawMessage, []string, error) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+		return raw, nil, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil || obj == nil {
+			// Let the final decode produce the type error.
+			return raw, nil, nil
+		}
+		fields := jsonFields(typ)
+
+		// Sort keys so warnings are deterministic.
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var warnings []string
+		out := make(map[string]json.RawMessage, len(obj))
+		// aliases holds the alias used for each canonical key that's absent.
+		aliases := map[string]string{}
+		for _, key := range keys {
+			field, ok := fields[normalizeKey(key)]
+			if !ok {
+				out[key] = obj[key]
+				continue
+			}
+			canonical := field.name
+			if key != canonical {
+				if _, exists := obj[canonical]; exists {
+					warnings = append(warnings, fmt.Sprintf("ignoring %q in favor of %q", path+key, path+canonical))
+					continue
+				}
+				if other, ok := aliases[canonical]; ok {
+					return nil, nil, &keyConflictError{field: path + canonical, keys: [2]string{path + other, path + key}}
+				}
+				aliases[canonical] = key
+			}
+			value, nested, err := normalizeKeys(obj[key], field.typ, path+canonical+".")
+			if err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, nested...)
+			out[canonical] = value
+		}
+		data, err := json.Marshal(out)
+		return data, warnings, err
+	case reflect.Slice, reflect.Array:
+		var arr []json.RawMessage
+		if json.Unmarshal(raw, &arr) != nil || arr == nil {
+			return raw, nil, nil
+		}
+		var warnings []string
+		for i := range arr {
+			value, nested, err := normalizeKeys(arr[i], typ.Elem(), fmt.Sprintf("%s%d.", path, i))
+			if err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, nested...)
+			arr[i] = value
+		}
+		data, err := json.Marshal(arr)
+		return data, warnings, err
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil || obj == nil {
+			return raw, nil, nil
+		}
+		var warnings []string
+		for key := range obj {
+			value, nested, err := normalizeKeys(obj[key], typ.Elem(), path+key+".")
+			if err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, nested...)
+			obj[key] = value
+		}
+		sort.Strings(warnings)
+		data, err := json.Marshal(obj)
+		return data, warnings, err
+	default:
+		return raw, nil, nil
+	}
+}
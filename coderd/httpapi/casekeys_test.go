@@ -0,0 +1,98 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadCaseInsensitiveKeys(t *testing.T) {
+	t.Parallel()
+
+	type owner struct {
+		DisplayName string `json:"display_name"`
+	}
+	type request struct {
+		TemplateName string  `json:"template_name" validate:"required"`
+		TTLMillis    int64   `json:"ttl_ms"`
+		Owners       []owner `json:"owners"`
+	}
+
+	t.Run("CamelCase", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"templateName":"docker","TtlMs":60000,"owners":[{"displayName":"Kyle"}]}`))
+
+		var v request
+		require.True(t, httpapi.ReadCaseInsensitiveKeys(context.Background(), rw, r, &v))
+		require.Equal(t, request{
+			TemplateName: "docker",
+			TTLMillis:    60000,
+			Owners:       []owner{{DisplayName: "Kyle"}},
+		}, v)
+		require.Empty(t, rw.Header().Values("Warning"))
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"templateName":"alias","template_name":"canonical"}`))
+
+		var v request
+		require.True(t, httpapi.ReadCaseInsensitiveKeys(context.Background(), rw, r, &v))
+		require.Equal(t, "canonical", v.TemplateName)
+		warnings := rw.Header().Values("Warning")
+		require.Len(t, warnings, 1)
+		require.Contains(t, warnings[0], "templateName")
+	})
+
+	t.Run("AliasConflict", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"templateName":"a","TemplateName":"b"}`))
+
+		var v request
+		require.False(t, httpapi.ReadCaseInsensitiveKeys(context.Background(), rw, r, &v))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "template_name", resp.Validations[0].Field)
+		require.Equal(t, "duplicate_field", resp.Validations[0].Code)
+		require.Contains(t, resp.Validations[0].Detail, `"TemplateName"`)
+		require.Contains(t, resp.Validations[0].Detail, `"templateName"`)
+	})
+
+	t.Run("NestedAliasConflict", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"template_name":"docker","owners":[{"displayName":"a","DisplayName":"b"}]}`))
+
+		var v request
+		require.False(t, httpapi.ReadCaseInsensitiveKeys(context.Background(), rw, r, &v))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "owners.0.display_name", resp.Validations[0].Field)
+	})
+
+	t.Run("ValidateFailure", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"ttlMs":1}`))
+
+		var v request
+		require.False(t, httpapi.ReadCaseInsensitiveKeys(context.Background(), rw, r, &v))
+	})
+}
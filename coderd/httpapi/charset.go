@@ -0,0 +1,47 @@
+package httpapi
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/xerrors"
+)
+
+// requestCharsets are the charsets Read accepts in the request Content-Type.
+// A nil encoding is already valid UTF-8 and needs no transcoding.
+var requestCharsets = map[string]encoding.Encoding{
+	"utf-8":        nil,
+	"utf8":         nil,
+	"us-ascii":     nil,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+}
+
+// transcodeBody returns body decoded to UTF-8 from the charset declared in
+// the request Content-Type. A missing charset is assumed to be UTF-8, and an
+// unsupported one is an error.
+func transcodeBody(r *http.Request, body io.Reader) (io.Reader, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		// Malformed or missing content types have never been rejected, so keep
+		// treating the body as UTF-8.
+		return body, nil
+	}
+	charset, ok := params["charset"]
+	if !ok {
+		return body, nil
+	}
+	enc, ok := requestCharsets[strings.ToLower(charset)]
+	if !ok {
+		return nil, xerrors.Errorf("charset %q is not supported, use utf-8", charset)
+	}
+	if enc == nil || body == nil {
+		return body, nil
+	}
+	return enc.NewDecoder().Reader(body), nil
+}
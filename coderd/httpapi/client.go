@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ReadResponse is the client-side counterpart to Write. On a 2xx status the
+// body is decoded into v, which may be nil to discard it. Any other status is
+// decoded as an ErrorResponse and returned as an *APIError, the same type
+// handlers return for WriteError, so it exposes the Message, Code and
+// Validations written by the server.
+//
+// The response body is always closed.
+func ReadResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readAPIError(resp)
+	}
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	err := json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return xerrors.Errorf("decode response body: %w", err)
+	}
+	return nil
+}
+
+// maxErrorBody is the number of bytes of an error response that ReadResponse
+// reads.
+const maxErrorBody = 1 << 20
+
+// readAPIError decodes a failed response. Bodies that aren't an ErrorResponse,
+// e.g. from a proxy in between, are summarized in the detail instead.
+func readAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{Status: resp.StatusCode}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err == nil && codersdk.ExpectJSONMime(resp) == nil && json.Unmarshal(body, &apiErr.ErrorResponse) == nil {
+		return apiErr
+	}
+
+	apiErr.ErrorResponse = ErrorResponse{
+		Response: codersdk.Response{
+			Message: fmt.Sprintf("Unexpected non-JSON response with status %q.", resp.Status),
+			Detail:  strings.TrimSpace(string(body)),
+		},
+	}
+	if len(apiErr.Detail) > maxUpstreamErrorBody {
+		apiErr.Detail = apiErr.Detail[:maxUpstreamErrorBody] + "... (truncated)"
+	}
+	return apiErr
+}
+
+// maxUpstreamErrorBody is the number of bytes of an upstream error body that
+// are included in the response detail.
+const maxUpstreamErrorBody = 1024
+
+// WriteUpstreamError translates a failed response from an upstream service
+// into one of ours. Upstream auth and server failures are our gateway's
+// problem rather than the client's, so they become a 502, while a missing
+// upstream resource is reported as missing. The start of the upstream body is
+// included in the detail for context.
+//
+// The upstream response body is always closed.
+func WriteUpstreamError(rw http.ResponseWriter, upstream *http.Response) {
+	defer upstream.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(upstream.Body, maxUpstreamErrorBody+1))
+	detail := strings.TrimSpace(string(body))
+	if len(body) > maxUpstreamErrorBody {
+		detail = strings.TrimSpace(string(body[:maxUpstreamErrorBody])) + "... (truncated)"
+	}
+	if detail == "" {
+		detail = upstream.Status
+	}
+
+	var (
+		status  int
+		message string
+	)
+	switch code := upstream.StatusCode; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		status, message = http.StatusBadGateway, "Upstream authentication failed."
+	case code == http.StatusNotFound:
+		status, message = http.StatusNotFound, "Upstream resource not found."
+	case code == http.StatusTooManyRequests:
+		status, message = http.StatusServiceUnavailable, "Upstream service is rate limiting requests."
+		if retryAfter := upstream.Header.Get("Retry-After"); retryAfter != "" {
+			rw.Header().Set("Retry-After", retryAfter)
+		}
+	case code == http.StatusGatewayTimeout:
+		status, message = http.StatusGatewayTimeout, "Upstream service timed out."
+	case code >= 400 && code < 500:
+		status, message = http.StatusBadGateway, "Upstream service rejected the request."
+	default:
+		status, message = http.StatusBadGateway, "Upstream service failed."
+	}
+	Write(context.Background(), rw, status, codersdk.Response{
+		Message: message,
+		Detail:  detail,
+	})
+}
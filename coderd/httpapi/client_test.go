@@ -0,0 +1,135 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadResponse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.Write(context.Background(), rw, http.StatusOK, codersdk.Response{
+			Message: "Wow.",
+		})
+
+		var v codersdk.Response
+		err := httpapi.ReadResponse(rw.Result(), &v)
+		require.NoError(t, err)
+		require.Equal(t, "Wow.", v.Message)
+	})
+
+	t.Run("ValidationError", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.Write(context.Background(), rw, http.StatusUnprocessableEntity, httpapi.ErrorResponse{
+			Response: codersdk.Response{
+				Message: "Validation failed.",
+				Validations: []codersdk.ValidationError{
+					{Field: "name", Detail: "Validation failed for tag \"required\" with value: \"\"", Code: "required"},
+				},
+			},
+			Code: httpapi.ErrorCodeValidationFailed,
+		})
+
+		var v codersdk.Response
+		err := httpapi.ReadResponse(rw.Result(), &v)
+		require.Error(t, err)
+		var apiErr *httpapi.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+		require.Equal(t, httpapi.ErrorCodeValidationFailed, apiErr.Code)
+		require.Equal(t, "Validation failed.", apiErr.Message)
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "name", apiErr.Validations[0].Field)
+		require.Equal(t, "required", apiErr.Validations[0].Code)
+		require.Empty(t, v.Message)
+	})
+
+	t.Run("NotJSON", func(t *testing.T) {
+		t.Parallel()
+		resp := &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Status:     "502 Bad Gateway",
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+			Body:       io.NopCloser(strings.NewReader("<h1>Bad Gateway</h1>")),
+		}
+
+		err := httpapi.ReadResponse(resp, nil)
+		var apiErr *httpapi.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadGateway, apiErr.Status)
+		require.Equal(t, "<h1>Bad Gateway</h1>", apiErr.Detail)
+	})
+}
+
+func TestWriteUpstreamError(t *testing.T) {
+	t.Parallel()
+
+	upstream := func(status int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		upstream int
+		status   int
+		message  string
+	}{
+		{name: "Unauthorized", upstream: http.StatusUnauthorized, status: http.StatusBadGateway, message: "Upstream authentication failed."},
+		{name: "NotFound", upstream: http.StatusNotFound, status: http.StatusNotFound, message: "Upstream resource not found."},
+		{name: "BadRequest", upstream: http.StatusBadRequest, status: http.StatusBadGateway, message: "Upstream service rejected the request."},
+		{name: "ServerError", upstream: http.StatusInternalServerError, status: http.StatusBadGateway, message: "Upstream service failed."},
+		{name: "Timeout", upstream: http.StatusGatewayTimeout, status: http.StatusGatewayTimeout, message: "Upstream service timed out."},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			httpapi.WriteUpstreamError(rw, upstream(tc.upstream, "upstream said no"))
+			require.Equal(t, tc.status, rw.Code)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Equal(t, tc.message, resp.Message)
+			require.Equal(t, "upstream said no", resp.Detail)
+		})
+	}
+
+	t.Run("Truncated", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteUpstreamError(rw, upstream(http.StatusInternalServerError, strings.Repeat("a", 4096)))
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Less(t, len(resp.Detail), 2048)
+		require.True(t, strings.HasSuffix(resp.Detail, "(truncated)"))
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		t.Parallel()
+		resp := upstream(http.StatusTooManyRequests, "")
+		resp.Header.Set("Retry-After", "30")
+		rw := httptest.NewRecorder()
+		httpapi.WriteUpstreamError(rw, resp)
+		require.Equal(t, http.StatusServiceUnavailable, rw.Code)
+		require.Equal(t, "30", rw.Header().Get("Retry-After"))
+	})
+}
@@ -0,0 +1,347 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v4"
+	"golang.org/x/xerrors"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeMsgpack  = "application/msgpack"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// ErrUnsupportedValue is returned by codecs that can't encode or decode a
+// value, such as protobuf for types that aren't proto.Message.
+var ErrUnsupportedValue = xerrors.New("value is not supported by the codec")
+
+// Codec encodes and decodes bodies in one format. Marshal and Unmarshal handle
+// whole bodies, and NewEncoder streams a sequence of values.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) Encoder
+}
+
+// Encoder writes a stream of values in the format of a Codec.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+var (
+	// JSONCodec is the default codec. Read and Write don't use it for JSON
+	// bodies, since they report errors in more detail.
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec encodes values as MessagePack, naming struct fields by
+	// their json tags so types need no extra tags. Types with custom JSON
+	// encoding but no msgpack equivalent are unsupported.
+	MsgpackCodec Codec = msgpackCodec{}
+	// ProtobufCodec encodes proto.Message values. Streams are length
+	// delimited, as written by protodelim.
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+type (
+	codecContextKey          struct{}
+	acceptedCodecsContextKey struct{}
+)
+
+// WithCodec returns a context that Write encodes responses in with codec.
+func WithCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, codecContextKey{}, codec)
+}
+
+// CodecFromContext returns the codec added by WithCodec, or JSONCodec. Handlers
+// streaming responses should encode them with it.
+func CodecFromContext(ctx context.Context) Codec {
+	if ctx == nil {
+		return JSONCodec
+	}
+	codec, ok := ctx.Value(codecContextKey{}).(Codec)
+	if !ok {
+		return JSONCodec
+	}
+	return codec
+}
+
+// WithAcceptedCodecs returns a context that Read decodes request bodies in
+// with codecs when their Content-Type asks for it. Bodies are read as JSON
+// otherwise. Routes opt in to codecs explicitly, since request types with
+// custom JSON decoding, like Optional or Duration, decode wrongly with them.
+func WithAcceptedCodecs(ctx context.Context, codecs ...Codec) context.Context {
+	return context.WithValue(ctx, acceptedCodecsContextKey{}, codecs)
+}
+
+// NegotiateCodec returns the codec out of JSONCodec and offered that the
+// request's Accept header ranks highest. JSONCodec is preferred when several
+// are accepted equally, and is returned if none are.
+func NegotiateCodec(r *http.Request, offered ...Codec) Codec {
+	best, bestQ := JSONCodec, acceptQuality(r, JSONCodec.ContentType())
+	for _, codec := range offered {
+		if q := acceptQuality(r, codec.ContentType()); q > bestQ {
+			best, bestQ = codec, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the highest q value the request's Accept header gives
+// mediaType, including through wildcards.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	var best float64
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			accepted, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			if accepted != mediaType && accepted != typ+"/*" && accepted != "*/*" {
+				continue
+			}
+			q := 1.0
+			if raw, ok := params["q"]; ok {
+				q, err = strconv.ParseFloat(raw, 64)
+				if err != nil {
+					continue
+				}
+			}
+			best = max(best, q)
+		}
+	}
+	return best
+}
+
+// requestCodec returns the codec of the request's Content-Type if the route
+// accepts it, see WithAcceptedCodecs. Bodies of any other type are read as
+// JSON.
+func requestCodec(r *http.Request) (Codec, bool) {
+	accepted, _ := r.Context().Value(acceptedCodecsContextKey{}).([]Codec)
+	if len(accepted) == 0 {
+		return nil, false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false
+	}
+	for _, codec := range accepted {
+		if codec != JSONCodec && codec.ContentType() == mediaType {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// readCodec is readBody for bodies in formats other than JSON. They're limited
+// to DefaultDecodeMaxBytes, since they're buffered whole.
+func readCodec(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}, codec Codec) bool {
+	var data []byte
+	if r.Body != nil {
+		var err error
+		data, err = io.ReadAll(http.MaxBytesReader(rw, r.Body, DefaultDecodeMaxBytes))
+		if err != nil {
+			if writeBodyTooLarge(ctx, rw, err) {
+				return false
+			}
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to read request body.",
+				Detail:  err.Error(),
+			})
+			return false
+		}
+	}
+	err := codec.Unmarshal(data, value)
+	if errors.Is(err, ErrUnsupportedValue) {
+		Write(ctx, rw, http.StatusUnsupportedMediaType, codersdk.Response{
+			Message: "Unsupported request body content type.",
+			Detail:  fmt.Sprintf("This endpoint doesn't accept %q bodies.", codec.ContentType()),
+		})
+		return false
+	}
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Request body must be valid %s.", codec.ContentType()),
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// writeCodec writes response encoded with codec. Values the codec doesn't
+// support, like error responses to a protobuf client, are written as JSON.
+func writeCodec(ctx context.Context, rw http.ResponseWriter, status int, response interface{}, codec Codec) {
+	start := time.Now()
+	data, err := codec.Marshal(response)
+	if err != nil {
+		writeJSON(ctx, rw, status, response, true, false)
+		return
+	}
+	writeBody(rw, status, codec.ContentType(), data)
+	observeResponse(ctx, status, len(data), time.Since(start))
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return ContentTypeJSON }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+func (c msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	if !msgpackSupports(reflect.TypeOf(v)) {
+		return ErrUnsupportedValue
+	}
+	return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(v)
+}
+
+func (msgpackCodec) NewEncoder(w io.Writer) Encoder {
+	return msgpackEncoder{enc: msgpack.NewEncoder(w).UseJSONTag(true)}
+}
+
+type msgpackEncoder struct {
+	enc *msgpack.Encoder
+}
+
+func (e msgpackEncoder) Encode(v interface{}) error {
+	if !msgpackSupports(reflect.TypeOf(v)) {
+		return ErrUnsupportedValue
+	}
+	return e.enc.Encode(v)
+}
+
+var (
+	msgpackEncoderTypes    = []reflect.Type{reflect.TypeOf((*msgpack.CustomEncoder)(nil)).Elem(), reflect.TypeOf((*msgpack.Marshaler)(nil)).Elem()}
+	msgpackDecoderTypes    = []reflect.Type{reflect.TypeOf((*msgpack.CustomDecoder)(nil)).Elem(), reflect.TypeOf((*msgpack.Unmarshaler)(nil)).Elem()}
+	msgpackSupportedByType sync.Map // map[reflect.Type]bool
+)
+
+// msgpackSupports returns whether values of t encode and decode the same in
+// msgpack as in JSON. msgpack names fields by their json tags, but never calls
+// MarshalJSON or UnmarshalJSON, so types that implement either without a
+// msgpack equivalent would silently lose their custom handling.
+func msgpackSupports(t reflect.Type) bool {
+	if t == nil {
+		return true
+	}
+	if supported, ok := msgpackSupportedByType.Load(t); ok {
+		return supported.(bool)
+	}
+	supported := msgpackSupportsType(t, map[reflect.Type]bool{})
+	msgpackSupportedByType.Store(t, supported)
+	return supported
+}
+
+func msgpackSupportsType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+
+	// msgpack encodes times as an extension type.
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	if implementsAny(t, jsonMarshalerType, jsonUnmarshalerType) {
+		return implementsAny(t, msgpackEncoderTypes...) && implementsAny(t, msgpackDecoderTypes...)
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return msgpackSupportsType(t.Elem(), seen)
+	case reflect.Map:
+		return msgpackSupportsType(t.Key(), seen) && msgpackSupportsType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Tag.Get("json") == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if !msgpackSupportsType(field.Type, seen) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// implementsAny returns whether t or *t implements any of ifaces.
+func implementsAny(t reflect.Type, ifaces ...reflect.Type) bool {
+	for _, iface := range ifaces {
+		if t.Implements(iface) || (t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(iface)) {
+			return true
+		}
+	}
+	return false
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrUnsupportedValue
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrUnsupportedValue
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (protobufCodec) NewEncoder(w io.Writer) Encoder {
+	return protobufEncoder{w: w}
+}
+
+type protobufEncoder struct {
+	w io.Writer
+}
+
+func (e protobufEncoder) Encode(v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrUnsupportedValue
+	}
+	_, err := protodelim.MarshalTo(e.w, msg)
+	return err
+}
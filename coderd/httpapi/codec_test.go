@@ -0,0 +1,213 @@
+package httpapi_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestNegotiateCodec(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		accept   string
+		expected httpapi.Codec
+	}{
+		{accept: "", expected: httpapi.JSONCodec},
+		{accept: "text/html, */*;q=0.8", expected: httpapi.JSONCodec},
+		{accept: "application/*", expected: httpapi.JSONCodec},
+		{accept: "application/msgpack", expected: httpapi.MsgpackCodec},
+		{accept: "application/x-protobuf, application/json;q=0.9", expected: httpapi.ProtobufCodec},
+		{accept: "application/msgpack;q=0, */*;q=0.1", expected: httpapi.JSONCodec},
+		{accept: "application/msgpack;q=bogus", expected: httpapi.JSONCodec},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", tc.accept)
+		require.Equal(t, tc.expected, httpapi.NegotiateCodec(r, httpapi.MsgpackCodec, httpapi.ProtobufCodec), tc.accept)
+	}
+
+	t.Run("NotOffered", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/msgpack")
+		require.Equal(t, httpapi.JSONCodec, httpapi.NegotiateCodec(r, httpapi.ProtobufCodec))
+	})
+}
+
+func TestReadCodec(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name  string `json:"name" validate:"required"`
+		Count int    `json:"count"`
+	}
+	read := func(t *testing.T, contentType string, body []byte, value interface{}) (*httptest.ResponseRecorder, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r = r.WithContext(httpapi.WithAcceptedCodecs(r.Context(), httpapi.MsgpackCodec, httpapi.ProtobufCodec))
+		return rw, httpapi.Read(r.Context(), rw, r, value)
+	}
+
+	t.Run("Msgpack", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"name": "dev", "count": 3})
+		require.NoError(t, err)
+		var v request
+		_, ok := read(t, httpapi.ContentTypeMsgpack, body, &v)
+		require.True(t, ok)
+		require.Equal(t, request{Name: "dev", Count: 3}, v)
+	})
+
+	t.Run("MsgpackValidated", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"count": 3})
+		require.NoError(t, err)
+		rw, ok := read(t, httpapi.ContentTypeMsgpack, body, &request{})
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "name", resp.Validations[0].Field)
+	})
+
+	t.Run("MsgpackNotAccepted", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"name": "dev"})
+		require.NoError(t, err)
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", httpapi.ContentTypeMsgpack)
+		require.False(t, httpapi.Read(context.Background(), rw, r, &request{}))
+		require.Equal(t, http.StatusBadRequest, rw.Code, "bodies are read as JSON")
+	})
+
+	t.Run("MsgpackCustomJSON", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"ttl": "1h"})
+		require.NoError(t, err)
+		var v struct {
+			TTL httpapi.Optional[httpapi.Duration] `json:"ttl"`
+		}
+		rw, ok := read(t, httpapi.ContentTypeMsgpack, body, &v)
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+
+	t.Run("MsgpackTooLarge", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"name": strings.Repeat("a", httpapi.DefaultDecodeMaxBytes)})
+		require.NoError(t, err)
+		rw, ok := read(t, httpapi.ContentTypeMsgpack, body, &request{})
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+
+	t.Run("MsgpackInvalid", func(t *testing.T) {
+		t.Parallel()
+		rw, ok := read(t, httpapi.ContentTypeMsgpack, []byte{0xc1}, &request{})
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("Protobuf", func(t *testing.T) {
+		t.Parallel()
+		body, err := proto.Marshal(wrapperspb.String("dev"))
+		require.NoError(t, err)
+		v := &wrapperspb.StringValue{}
+		_, ok := read(t, httpapi.ContentTypeProtobuf, body, v)
+		require.True(t, ok)
+		require.Equal(t, "dev", v.GetValue())
+	})
+
+	t.Run("ProtobufUnsupported", func(t *testing.T) {
+		t.Parallel()
+		body, err := proto.Marshal(wrapperspb.String("dev"))
+		require.NoError(t, err)
+		rw, ok := read(t, httpapi.ContentTypeProtobuf, body, &request{})
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+}
+
+func TestWriteCodec(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Msgpack", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		ctx := httpapi.WithCodec(context.Background(), httpapi.MsgpackCodec)
+		httpapi.Write(ctx, rw, http.StatusCreated, codersdk.Response{Message: "created"})
+		require.Equal(t, http.StatusCreated, rw.Code)
+		require.Equal(t, httpapi.ContentTypeMsgpack, rw.Header().Get("Content-Type"))
+
+		var resp map[string]interface{}
+		require.NoError(t, msgpack.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, "created", resp["message"], "fields are named by their json tags")
+	})
+
+	t.Run("Protobuf", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		ctx := httpapi.WithCodec(context.Background(), httpapi.ProtobufCodec)
+		httpapi.Write(ctx, rw, http.StatusOK, wrapperspb.String("hello"))
+		require.Equal(t, httpapi.ContentTypeProtobuf, rw.Header().Get("Content-Type"))
+		v := &wrapperspb.StringValue{}
+		require.NoError(t, proto.Unmarshal(rw.Body.Bytes(), v))
+		require.Equal(t, "hello", v.GetValue())
+	})
+
+	t.Run("ProtobufFallback", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		ctx := httpapi.WithCodec(context.Background(), httpapi.ProtobufCodec)
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{Message: "not found"})
+		require.Equal(t, http.StatusNotFound, rw.Code)
+		require.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "not found", resp.Message)
+	})
+
+	t.Run("MsgpackCustomJSONFallback", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		ctx := httpapi.WithCodec(context.Background(), httpapi.MsgpackCodec)
+		httpapi.Write(ctx, rw, http.StatusOK, map[string]httpapi.Duration{"ttl": httpapi.Duration(time.Hour)})
+		require.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"ttl":"1h0m0s"}`, rw.Body.String())
+	})
+
+	t.Run("ProtobufStream", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		enc := httpapi.ProtobufCodec.NewEncoder(&buf)
+		for _, s := range []string{"one", "two"} {
+			require.NoError(t, enc.Encode(wrapperspb.String(s)))
+		}
+		require.ErrorIs(t, enc.Encode("three"), httpapi.ErrUnsupportedValue)
+
+		r := bufio.NewReader(&buf)
+		for _, s := range []string{"one", "two"} {
+			v := &wrapperspb.StringValue{}
+			require.NoError(t, protodelim.UnmarshalFrom(r, v))
+			require.Equal(t, s, v.GetValue())
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// WriteWithLastModified is like Write, but sets Last-Modified to modTime and
+// responds with a bodiless 304 to GET and HEAD requests whose If-Modified-Since
+// is at or after it. HTTP dates have second precision, so modTime is truncated
+// before comparing. Requests demanding revalidation with Cache-Control
+// no-cache or max-age=0 always get the full body.
+func WriteWithLastModified(rw http.ResponseWriter, r *http.Request, status int, response interface{}, modTime time.Time) {
+	modTime = modTime.UTC().Truncate(time.Second)
+	if !modTime.IsZero() {
+		rw.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+	}
+
+	if conditionalRequest(r) && !modTime.IsZero() {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err == nil && !since.Before(modTime) {
+			writeNotModified(rw, r)
+			return
+		}
+	}
+
+	Write(r.Context(), rw, status, response)
+}
+
+// WriteWithETag is like Write, but sets the ETag header to etag, quoting it
+// if needed, and responds with a bodiless 304 to GET and HEAD requests whose
+// If-None-Match holds it. Tags are compared weakly. Requests demanding
+// revalidation with Cache-Control no-cache or max-age=0 always get the full
+// body.
+func WriteWithETag(rw http.ResponseWriter, r *http.Request, status int, response interface{}, etag string) {
+	if !strings.HasSuffix(etag, `"`) {
+		etag = strconv.Quote(etag)
+	}
+	rw.Header().Set("ETag", etag)
+
+	if conditionalRequest(r) && etagMatches(r.Header.Get("If-None-Match"), etag, false) {
+		writeNotModified(rw, r)
+		return
+	}
+
+	Write(r.Context(), rw, status, response)
+}
+
+// WriteConditional is like WriteWithETag, but the ETag is a strong one computed
+// from the encoded response, so handlers don't need to version their
+// resources. Polling clients that send it back in If-None-Match get a
+// bodiless 304 until the response changes. Only 200 responses get an ETag.
+func WriteConditional(rw http.ResponseWriter, r *http.Request, status int, response interface{}) {
+	if status != http.StatusOK {
+		Write(r.Context(), rw, status, response)
+		return
+	}
+	_, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	start := time.Now()
+	contentType, body, err := encodeResponse(r.Context(), response)
+	encodeLatency := time.Since(start)
+	if err != nil {
+		// Write responds with the standard 500.
+		Write(r.Context(), rw, status, response)
+		return
+	}
+	etag := bodyETag(body)
+	rw.Header().Set("ETag", etag)
+
+	if conditionalRequest(r) && etagMatches(r.Header.Get("If-None-Match"), etag, false) {
+		writeNotModified(rw, r)
+		return
+	}
+	writeBody(rw, status, contentType, body)
+	observeResponse(r.Context(), status, len(body), encodeLatency)
+}
+
+// CheckETag enforces an If-Match precondition against the ETag WriteConditional
+// sends for current, the resource as it is before the update. Update endpoints
+// use it so clients don't overwrite changes they haven't seen. Requests
+// without If-Match, or with "*", always pass. Weak tags never match. A
+// mismatch writes a 412 and false is returned.
+func CheckETag(rw http.ResponseWriter, r *http.Request, current interface{}) bool {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return true
+	}
+	_, body, err := encodeResponse(r.Context(), current)
+	if err != nil {
+		InternalServerError(rw, err)
+		return false
+	}
+	if etagMatches(header, bodyETag(body), true) {
+		return true
+	}
+
+	Write(r.Context(), rw, http.StatusPreconditionFailed, codersdk.Response{
+		Message: "The resource has been modified.",
+		Detail:  "The If-Match precondition doesn't match the current ETag of the resource. Fetch it again and retry.",
+	})
+	return false
+}
+
+// encodeResponse encodes response like Write does, returning its content type.
+func encodeResponse(ctx context.Context, response interface{}) (string, []byte, error) {
+	if codec := CodecFromContext(ctx); codec != JSONCodec {
+		if body, err := codec.Marshal(response); err == nil {
+			return codec.ContentType(), body, nil
+		}
+	}
+	body, err := encodeJSON(response, true, flag.Lookup("test.v") != nil)
+	return "application/json; charset=utf-8", body, err
+}
+
+// bodyETag returns a strong ETag of an encoded body.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches returns whether the comma-separated tags of header include etag
+// or "*". The strong comparison of If-Match treats weak tags as never
+// matching, while the weak comparison of If-None-Match ignores the W/ prefix.
+func etagMatches(header, etag string, strong bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strong {
+			if !strings.HasPrefix(tag, "W/") && !strings.HasPrefix(etag, "W/") && tag == etag {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeNotModified(rw http.ResponseWriter, r *http.Request) {
+	rw.WriteHeader(http.StatusNotModified)
+	observeResponse(r.Context(), http.StatusNotModified, 0, 0)
+}
+
+// conditionalRequest returns whether a 304 may be sent in response to r. The
+// client can demand the full body with Cache-Control no-cache or max-age=0,
+// e.g. when it suspects its copy is corrupt.
+func conditionalRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			return false
+		case "max-age":
+			if strings.Trim(value, `"`) == "0" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CheckVersion enforces an If-Match precondition holding an integer resource
+// version, e.g. `If-Match: "7"`, against the current version. Requests
+// without If-Match, or with "*", always pass. A mismatch writes a 412 and an
+// unparsable precondition a 400, and false is returned.
+func CheckVersion(rw http.ResponseWriter, r *http.Request, current int64) bool {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		unquoted := strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`)
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err != nil {
+			Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid If-Match precondition.",
+				Detail:  fmt.Sprintf("%s is not an integer resource version.", tag),
+				Validations: []codersdk.ValidationError{
+					{Field: "If-Match", Detail: "must be a quoted integer version", Code: "precondition"},
+				},
+			})
+			return false
+		}
+		if version == current {
+			return true
+		}
+		versions = append(versions, version)
+	}
+
+	Write(r.Context(), rw, http.StatusPreconditionFailed, codersdk.Response{
+		Message: "The resource has been modified.",
+		Detail:  fmt.Sprintf("Expected version %v, but the current version is %d.", versions, current),
+	})
+	return false
+}
@@ -0,0 +1,229 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWriteWithLastModified(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, 6, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	for _, tc := range []struct {
+		name         string
+		method       string
+		since        string
+		cacheControl string
+		status       int
+		hasBody      bool
+	}{
+		{name: "NoHeader", method: "GET", status: http.StatusOK, hasBody: true},
+		// The sub-second part of modTime must not make this look modified.
+		{name: "NotModified", method: "GET", since: modTime.Truncate(time.Second).Format(http.TimeFormat), status: http.StatusNotModified},
+		{name: "NotModifiedLater", method: "GET", since: modTime.Add(time.Hour).Format(http.TimeFormat), status: http.StatusNotModified},
+		{name: "Modified", method: "GET", since: modTime.Add(-time.Hour).Format(http.TimeFormat), status: http.StatusOK, hasBody: true},
+		{name: "InvalidDate", method: "GET", since: "yesterday", status: http.StatusOK, hasBody: true},
+		{name: "Put", method: "PUT", since: modTime.Add(time.Hour).Format(http.TimeFormat), status: http.StatusOK, hasBody: true},
+		{name: "NoCache", method: "GET", since: modTime.Add(time.Hour).Format(http.TimeFormat), cacheControl: "no-cache", status: http.StatusOK, hasBody: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, "/", nil)
+			if tc.since != "" {
+				r.Header.Set("If-Modified-Since", tc.since)
+			}
+			if tc.cacheControl != "" {
+				r.Header.Set("Cache-Control", tc.cacheControl)
+			}
+			httpapi.WriteWithLastModified(rw, r, http.StatusOK, codersdk.Response{Message: "Hi."}, modTime)
+			require.Equal(t, tc.status, rw.Code)
+			require.Equal(t, "Sat, 01 Jun 2024 12:00:00 GMT", rw.Header().Get("Last-Modified"))
+			if tc.hasBody {
+				require.Contains(t, rw.Body.String(), "Hi.")
+			} else {
+				require.Empty(t, rw.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestWriteWithETag(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		ifNoneMatch  string
+		cacheControl string
+		status       int
+	}{
+		{name: "NoHeader", status: http.StatusOK},
+		{name: "Match", ifNoneMatch: `"v7"`, status: http.StatusNotModified},
+		{name: "MatchList", ifNoneMatch: `"v6", W/"v7"`, status: http.StatusNotModified},
+		{name: "Any", ifNoneMatch: "*", status: http.StatusNotModified},
+		{name: "Mismatch", ifNoneMatch: `"v6"`, status: http.StatusOK},
+		{name: "NoCache", ifNoneMatch: `"v7"`, cacheControl: "no-cache", status: http.StatusOK},
+		{name: "MaxAgeZero", ifNoneMatch: `"v7"`, cacheControl: "max-age=0", status: http.StatusOK},
+		{name: "MaxAge", ifNoneMatch: `"v7"`, cacheControl: "max-age=60", status: http.StatusNotModified},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			if tc.cacheControl != "" {
+				r.Header.Set("Cache-Control", tc.cacheControl)
+			}
+			httpapi.WriteWithETag(rw, r, http.StatusOK, codersdk.Response{Message: "Hi."}, "v7")
+			require.Equal(t, tc.status, rw.Code)
+			require.Equal(t, `"v7"`, rw.Header().Get("ETag"))
+			if tc.status == http.StatusOK {
+				require.Contains(t, rw.Body.String(), "Hi.")
+			} else {
+				require.Empty(t, rw.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		ifMatch string
+		ok      bool
+		status  int
+	}{
+		{name: "NoPrecondition", ok: true},
+		{name: "Any", ifMatch: "*", ok: true},
+		{name: "Match", ifMatch: `"7"`, ok: true},
+		{name: "MatchList", ifMatch: `"6", "7"`, ok: true},
+		{name: "Mismatch", ifMatch: `"6"`, status: http.StatusPreconditionFailed},
+		{name: "Malformed", ifMatch: `"seven"`, status: http.StatusBadRequest},
+		{name: "Weak", ifMatch: `W/"7"`, status: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("PUT", "/", nil)
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
+			ok := httpapi.CheckVersion(rw, r, 7)
+			require.Equal(t, tc.ok, ok)
+			if !tc.ok {
+				require.Equal(t, tc.status, rw.Code)
+				var resp codersdk.Response
+				require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+				require.NotEmpty(t, resp.Message)
+			}
+		})
+	}
+}
+
+func TestWriteConditional(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, method, ifNoneMatch string, response interface{}) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		httpapi.WriteConditional(rw, r, http.StatusOK, response)
+		return rw
+	}
+
+	first := write(t, "GET", "", codersdk.Response{Message: "Hi."})
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	var resp codersdk.Response
+	require.NoError(t, json.NewDecoder(first.Body).Decode(&resp))
+	require.Equal(t, "Hi.", resp.Message)
+
+	t.Run("Stable", func(t *testing.T) {
+		t.Parallel()
+		rw := write(t, "GET", "", codersdk.Response{Message: "Hi."})
+		require.Equal(t, etag, rw.Header().Get("ETag"))
+	})
+
+	t.Run("NotModified", func(t *testing.T) {
+		t.Parallel()
+		rw := write(t, "GET", etag, codersdk.Response{Message: "Hi."})
+		require.Equal(t, http.StatusNotModified, rw.Code)
+		require.Equal(t, etag, rw.Header().Get("ETag"))
+		require.Empty(t, rw.Body.Bytes())
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		t.Parallel()
+		rw := write(t, "GET", etag, codersdk.Response{Message: "Bye."})
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.NotEqual(t, etag, rw.Header().Get("ETag"))
+		require.Contains(t, rw.Body.String(), "Bye.")
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		t.Parallel()
+		rw := write(t, "POST", etag, codersdk.Response{Message: "Hi."})
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+
+	t.Run("NotOK", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("If-None-Match", "*")
+		httpapi.WriteConditional(rw, r, http.StatusNotFound, codersdk.Response{Message: "Not found."})
+		require.Equal(t, http.StatusNotFound, rw.Code)
+		require.Empty(t, rw.Header().Get("ETag"))
+	})
+}
+
+func TestCheckETag(t *testing.T) {
+	t.Parallel()
+
+	current := codersdk.Response{Message: "v1"}
+	rw := httptest.NewRecorder()
+	httpapi.WriteConditional(rw, httptest.NewRequest("GET", "/", nil), http.StatusOK, current)
+	etag := rw.Header().Get("ETag")
+
+	for _, tc := range []struct {
+		name    string
+		ifMatch string
+		ok      bool
+	}{
+		{name: "NoHeader", ok: true},
+		{name: "Any", ifMatch: "*", ok: true},
+		{name: "Match", ifMatch: etag, ok: true},
+		{name: "MatchList", ifMatch: `"stale", ` + etag, ok: true},
+		{name: "Weak", ifMatch: "W/" + etag},
+		{name: "Mismatch", ifMatch: `"stale"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("PATCH", "/", nil)
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
+			require.Equal(t, tc.ok, httpapi.CheckETag(rw, r, current))
+			if !tc.ok {
+				require.Equal(t, http.StatusPreconditionFailed, rw.Code)
+			}
+		})
+	}
+}
@@ -1,12 +1,41 @@
 package httpapi
 
 import (
+	"net/http"
 	"net/textproto"
 	"strings"
 
+	"golang.org/x/xerrors"
+
 	"github.com/coder/coder/v2/codersdk"
 )
 
+// InsecureCookies disables the Secure flag enforced by SetCookie. It should
+// only be enabled for local development over plain HTTP.
+var InsecureCookies = false
+
+// SetCookie sets cookie on the response with safe defaults: HttpOnly is always
+// set, Secure is set unless InsecureCookies is enabled, and SameSite defaults
+// to Lax when unset. Cookies with a name or value that could inject headers
+// are rejected, rather than silently dropped like http.SetCookie does.
+func SetCookie(rw http.ResponseWriter, cookie http.Cookie) error {
+	if strings.ContainsAny(cookie.Name+cookie.Value, "\r\n") {
+		return xerrors.Errorf("cookie %q contains a newline", cookie.Name)
+	}
+	err := cookie.Valid()
+	if err != nil {
+		return xerrors.Errorf("invalid cookie %q: %w", cookie.Name, err)
+	}
+
+	cookie.HttpOnly = true
+	cookie.Secure = cookie.Secure || !InsecureCookies
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	http.SetCookie(rw, &cookie)
+	return nil
+}
+
 // StripCoderCookies removes the session token from the cookie header provided.
 func StripCoderCookies(header string) string {
 	header = textproto.TrimString(header)
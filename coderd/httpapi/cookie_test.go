@@ -1,6 +1,8 @@
 package httpapi_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -33,3 +35,51 @@ func TestStripCoderCookies(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCookie(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		err := httpapi.SetCookie(rw, http.Cookie{
+			Name:  "session",
+			Value: "abc123",
+			Path:  "/",
+		})
+		require.NoError(t, err)
+
+		cookies := rw.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "abc123", cookies[0].Value)
+		require.True(t, cookies[0].HttpOnly)
+		require.True(t, cookies[0].Secure)
+		require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("KeepsSameSite", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		err := httpapi.SetCookie(rw, http.Cookie{
+			Name:     "session",
+			Value:    "abc123",
+			SameSite: http.SameSiteStrictMode,
+		})
+		require.NoError(t, err)
+
+		cookies := rw.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	})
+
+	t.Run("RejectsNewline", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		err := httpapi.SetCookie(rw, http.Cookie{
+			Name:  "session",
+			Value: "abc\r\nSet-Cookie: evil=1",
+		})
+		require.Error(t, err)
+		require.Empty(t, rw.Header().Values("Set-Cookie"))
+	})
+}
@@ -0,0 +1,278 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// DefaultDecodeMaxBytes is the largest body Decode accepts.
+const DefaultDecodeMaxBytes = 10 << 20
+
+// Decode is a strict alternative to Read, which decodes the JSON body into a new
+// T and validates it. Unlike Read, fields that T doesn't have and data after
+// the JSON value are rejected, so typos like "ttl_ms" for "ttl" aren't
+// silently ignored. Bodies larger than DefaultDecodeMaxBytes are rejected with
+// a 413.
+//
+// Malformed bodies are a 400 ErrorResponse, whose code tells empty bodies,
+// syntax errors, type mismatches and unknown fields apart. The validations
+// name the JSON path of the offending value, e.g. "resources[1].name".
+func Decode[T any](rw http.ResponseWriter, r *http.Request) (T, bool) {
+	return DecodeLimited[T](rw, r, DefaultDecodeMaxBytes)
+}
+
+// DecodeLimited is like Decode, but rejects bodies larger than maxBytes.
+func DecodeLimited[T any](rw http.ResponseWriter, r *http.Request, maxBytes int64) (T, bool) {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	var value T
+	var data []byte
+	if r.Body != nil {
+		body, err := transcodeBody(r, skipBOM(http.MaxBytesReader(rw, r.Body, maxBytes)))
+		if err != nil {
+			Write(ctx, rw, http.StatusUnsupportedMediaType, codersdk.Response{
+				Message: "Unsupported request body charset.",
+				Detail:  err.Error(),
+			})
+			return value, false
+		}
+		data, err = io.ReadAll(body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				status, response := mapError(err)
+				Write(ctx, rw, status, response)
+				return value, false
+			}
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to read request body.",
+				Detail:  err.Error(),
+			})
+			return value, false
+		}
+	}
+
+	if problem := decodeStrict(data, &value); problem != nil {
+		observeDecodeFailures(ctx, reflect.TypeOf(value), problem.Validations, problem.Code)
+		Write(ctx, rw, http.StatusBadRequest, *problem)
+		return value, false
+	}
+	if !validateRequest(ctx, rw, r, &value) {
+		return value, false
+	}
+	return value, true
+}
+
+// decodeStrict decodes data into value, returning the response to write if
+// it's rejected.
+func decodeStrict(data []byte, value any) *ErrorResponse {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &ErrorResponse{
+			Response: codersdk.Response{Message: "Request body must not be empty."},
+			Code:     ErrorCodeEmptyBody,
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(value)
+	if err == nil {
+		// Anything but whitespace after the value is an error, including a
+		// second value.
+		offset := dec.InputOffset()
+		if _, tokErr := dec.Token(); !errors.Is(tokErr, io.EOF) {
+			return invalidJSON(data, offset, "unexpected data after the JSON value")
+		}
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &ErrorResponse{
+			Response: codersdk.Response{
+				Message:     "Request body has a value of the wrong type.",
+				Detail:      err.Error(),
+				Validations: []codersdk.ValidationError{typeErrorValidation(typeErr, data)},
+			},
+			Code: ErrorCodeInvalidType,
+		}
+	}
+	if name, ok := unknownFieldName(err); ok {
+		path := name
+		var raw any
+		if json.Unmarshal(data, &raw) == nil {
+			if found := unknownFieldPath(raw, reflect.TypeOf(value), name, ""); found != "" {
+				path = found
+			}
+		}
+		return &ErrorResponse{
+			Response: codersdk.Response{
+				Message: "Request body has an unknown field.",
+				Detail:  err.Error(),
+				Validations: []codersdk.ValidationError{{
+					Field:  path,
+					Detail: fmt.Sprintf("%q is not a known field", name),
+					Code:   string(ErrorCodeUnknownField),
+				}},
+			},
+			Code: ErrorCodeUnknownField,
+		}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return invalidJSON(data, syntaxErr.Offset, syntaxErr.Error())
+	}
+	// A truncated body is io.ErrUnexpectedEOF, and anything else is from a
+	// custom UnmarshalJSON.
+	return &ErrorResponse{
+		Response: codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		},
+		Code: ErrorCodeInvalidJSON,
+	}
+}
+
+// invalidJSON returns the response for a syntax error at offset, which names
+// the path of the value it's in if there is one.
+func invalidJSON(data []byte, offset int64, detail string) *ErrorResponse {
+	resp := &ErrorResponse{
+		Response: codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  fmt.Sprintf("%s at offset %d", detail, offset),
+		},
+		Code: ErrorCodeInvalidJSON,
+	}
+	if offset > 0 && offset <= int64(len(data)) {
+		if path := jsonPathAt(data[:offset]); path != "" {
+			resp.Validations = []codersdk.ValidationError{{
+				Field:  path,
+				Detail: detail,
+				Code:   string(ErrorCodeInvalidJSON),
+			}}
+		}
+	}
+	return resp
+}
+
+// unknownFieldName returns the field named by the error encoding/json returns
+// for unknown fields, which isn't a distinct type.
+func unknownFieldName(err error) (string, bool) {
+	rest, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	name, err := strconv.Unquote(rest)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// unknownFieldPath walks raw, the body decoded generically, alongside typ to
+// find the path of the object key name that typ has no field for.
+func unknownFieldPath(raw any, typ reflect.Type, name, path string) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Implements(jsonUnmarshalerType) || reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+		return ""
+	}
+
+	switch value := raw.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			switch typ.Kind() {
+			case reflect.Map:
+				if found := unknownFieldPath(value[key], typ.Elem(), name, keyPath); found != "" {
+					return found
+				}
+			case reflect.Struct:
+				field, ok := structFieldForKey(typ, key)
+				if !ok {
+					if key == name {
+						return keyPath
+					}
+					continue
+				}
+				if found := unknownFieldPath(value[key], field.Type, name, keyPath); found != "" {
+					return found
+				}
+			}
+		}
+	case []any:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return ""
+		}
+		for i, elem := range value {
+			if found := unknownFieldPath(elem, typ.Elem(), name, path+"["+strconv.Itoa(i)+"]"); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+// structFieldForKey returns the field of typ that encoding/json decodes key into,
+// including fields promoted from embedded structs. Like encoding/json, an
+// exact match is preferred over a case-insensitive one.
+func structFieldForKey(typ reflect.Type, key string) (reflect.StructField, bool) {
+	var fold *reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.SplitN(tag, ",", 2)[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f, ok := structFieldForKey(embedded, key); ok {
+					return f, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = &field
+		}
+	}
+	if fold != nil {
+		return *fold, true
+	}
+	return reflect.StructField{}, false
+}
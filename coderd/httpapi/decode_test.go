@@ -0,0 +1,134 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	type agent struct {
+		Name string `json:"name"`
+	}
+	type resource struct {
+		Name   string  `json:"name"`
+		Agents []agent `json:"agents"`
+	}
+	type request struct {
+		Name      string     `json:"name" validate:"required"`
+		TTL       int64      `json:"ttl"`
+		Resources []resource `json:"resources"`
+	}
+	decode := func(t *testing.T, body string) (*httptest.ResponseRecorder, request, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		v, ok := httpapi.DecodeLimited[request](rw, r, 512)
+		return rw, v, ok
+	}
+	problem := func(t *testing.T, rw *httptest.ResponseRecorder) httpapi.ErrorResponse {
+		var resp httpapi.ErrorResponse
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := decode(t, `{"name":"dev","ttl":60,"resources":[{"name":"vm","agents":[{"name":"main"}]}]}`)
+		require.True(t, ok)
+		require.Equal(t, "dev", v.Name)
+		require.Equal(t, int64(60), v.TTL)
+		require.Equal(t, "main", v.Resources[0].Agents[0].Name)
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev","ttl_ms":60}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := problem(t, rw)
+		require.Equal(t, httpapi.ErrorCodeUnknownField, resp.Code)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "ttl_ms", resp.Validations[0].Field)
+	})
+
+	t.Run("NestedUnknownField", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev","resources":[{"name":"vm"},{"agents":[{"nmae":"main"}]}]}`)
+		require.False(t, ok)
+		resp := problem(t, rw)
+		require.Equal(t, httpapi.ErrorCodeUnknownField, resp.Code)
+		require.Equal(t, "resources[1].agents[0].nmae", resp.Validations[0].Field)
+	})
+
+	t.Run("CaseInsensitiveField", func(t *testing.T) {
+		t.Parallel()
+		// encoding/json matches keys case-insensitively, so these are known.
+		_, v, ok := decode(t, `{"Name":"dev","TTL":5}`)
+		require.True(t, ok)
+		require.Equal(t, "dev", v.Name)
+	})
+
+	t.Run("TypeMismatch", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev","resources":[{"name":"vm"},{"name":5}]}`)
+		require.False(t, ok)
+		resp := problem(t, rw)
+		require.Equal(t, httpapi.ErrorCodeInvalidType, resp.Code)
+		require.Equal(t, "resources[1].name", resp.Validations[0].Field)
+	})
+
+	t.Run("Syntax", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev","ttl":6o}`)
+		require.False(t, ok)
+		resp := problem(t, rw)
+		require.Equal(t, httpapi.ErrorCodeInvalidJSON, resp.Code)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev"`)
+		require.False(t, ok)
+		require.Equal(t, httpapi.ErrorCodeInvalidJSON, problem(t, rw).Code)
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"dev"} {"name":"prod"}`)
+		require.False(t, ok)
+		require.Equal(t, httpapi.ErrorCodeInvalidJSON, problem(t, rw).Code)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, "  \n")
+		require.False(t, ok)
+		require.Equal(t, httpapi.ErrorCodeEmptyBody, problem(t, rw).Code)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"name":"`+strings.Repeat("a", 512)+`"}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		require.Equal(t, httpapi.ErrorCodePayloadTooLarge, problem(t, rw).Code)
+	})
+
+	t.Run("ValidationFailure", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := decode(t, `{"ttl":60}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := problem(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "name", resp.Validations[0].Field)
+	})
+}
@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// WriteEnvelope is like Write, but wraps the response under a top-level
+// "data" key. Paired with WriteEnvelopeError, clients can parse success and
+// failure bodies uniformly by checking which key is present.
+func WriteEnvelope(ctx context.Context, rw http.ResponseWriter, status int, data interface{}) {
+	Write(ctx, rw, status, struct {
+		Data interface{} `json:"data"`
+	}{Data: data})
+}
+
+// WriteEnvelopeError is the failure counterpart of WriteEnvelope. The response
+// is wrapped under a top-level "error" key.
+func WriteEnvelopeError(ctx context.Context, rw http.ResponseWriter, status int, response codersdk.Response) {
+	Write(ctx, rw, status, struct {
+		Error codersdk.Response `json:"error"`
+	}{Error: response})
+}
+
+// ReadUnwrapped is like Read, but for bodies wrapped in a top-level object,
+// such as {"data": {...}}. The object under wrapperKey is decoded into value
+// and validated, and other top-level keys are ignored. A missing or null
+// wrapper is rejected with a 400.
+func ReadUnwrapped(rw http.ResponseWriter, r *http.Request, wrapperKey string, value interface{}) bool {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	var wrapper map[string]json.RawMessage
+	err := json.NewDecoder(skipBOM(r.Body)).Decode(&wrapper)
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	raw, ok := wrapper[wrapperKey]
+	if !ok || bytes.Equal(raw, []byte("null")) {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Request body must be wrapped in a %q object.", wrapperKey),
+			Validations: []codersdk.ValidationError{
+				{Field: wrapperKey, Detail: fmt.Sprintf("%q is required", wrapperKey), Code: "required"},
+			},
+		})
+		return false
+	}
+	err = json.Unmarshal(raw, value)
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  fmt.Sprintf("decode %q: %s", wrapperKey, err.Error()),
+		})
+		return false
+	}
+	return validateRequest(ctx, rw, r, value)
+}
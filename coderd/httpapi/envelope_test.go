@@ -0,0 +1,106 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWriteEnvelope(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Data", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteEnvelope(context.Background(), rw, http.StatusOK, map[string]string{"name": "coder"})
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var m map[string]json.RawMessage
+		err := json.NewDecoder(rw.Body).Decode(&m)
+		require.NoError(t, err)
+		require.Len(t, m, 1)
+		require.JSONEq(t, `{"name":"coder"}`, string(m["data"]))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteEnvelopeError(context.Background(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Bad.",
+			Validations: []codersdk.ValidationError{
+				{Field: "name", Detail: "required"},
+			},
+		})
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var m map[string]json.RawMessage
+		err := json.NewDecoder(rw.Body).Decode(&m)
+		require.NoError(t, err)
+		require.Len(t, m, 1)
+		require.JSONEq(t, `{"message":"Bad.","validations":[{"field":"name","detail":"required"}]}`, string(m["error"]))
+	})
+}
+
+func TestReadUnwrapped(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+	read := func(t *testing.T, body string) (*httptest.ResponseRecorder, request, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		var v request
+		ok := httpapi.ReadUnwrapped(rw, r, "data", &v)
+		return rw, v, ok
+	}
+	decode := func(t *testing.T, rw *httptest.ResponseRecorder) codersdk.Response {
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("Wrapped", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, `{"data":{"name":"dev"},"meta":{"source":"partner"}}`)
+		require.True(t, ok)
+		require.Equal(t, request{Name: "dev"}, v)
+	})
+
+	t.Run("MissingWrapper", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"name":"dev"}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "data", resp.Validations[0].Field)
+		require.Equal(t, "required", resp.Validations[0].Code)
+	})
+
+	t.Run("EmptyWrapper", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"data":{}}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "name", resp.Validations[0].Field)
+	})
+
+	t.Run("NotAnObject", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"data":"dev"}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, decode(t, rw).Detail, `decode "data"`)
+	})
+}
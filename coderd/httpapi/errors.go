@@ -0,0 +1,153 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ErrorCode is a machine-readable identifier for the kind of error in an
+// ErrorResponse. Unlike the message, codes are stable and safe to match on.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest   ErrorCode = "invalid_request"
+	ErrorCodeValidationFailed ErrorCode = "validation_failed"
+	ErrorCodeNotFound         ErrorCode = "not_found"
+	ErrorCodeForbidden        ErrorCode = "forbidden"
+	ErrorCodeConflict         ErrorCode = "conflict"
+	ErrorCodePayloadTooLarge  ErrorCode = "payload_too_large"
+	ErrorCodeTimeout          ErrorCode = "timeout"
+	ErrorCodeInternal         ErrorCode = "internal"
+
+	// Codes of malformed bodies rejected by Decode.
+	ErrorCodeEmptyBody    ErrorCode = "empty_body"
+	ErrorCodeInvalidJSON  ErrorCode = "invalid_json"
+	ErrorCodeInvalidType  ErrorCode = "invalid_type"
+	ErrorCodeUnknownField ErrorCode = "unknown_field"
+)
+
+// ErrorResponse is an error response with a code in addition to the standard
+// fields, so existing clients decoding a codersdk.Response keep working.
+type ErrorResponse struct {
+	codersdk.Response
+	Code ErrorCode `json:"code"`
+	// DocumentationURL links to docs explaining the error.
+	DocumentationURL string `json:"documentation_url,omitempty"`
+	// Errors are the underlying errors, e.g. for each item of a batch.
+	Errors []ErrorResponse `json:"errors,omitempty"`
+}
+
+// APIError is an error that carries the response it should be written as.
+// Handlers and the functions they call can return it, wrapped or not, for
+// WriteError to write. On the client side, ReadResponse returns it for failed
+// responses.
+type APIError struct {
+	Status int
+	ErrorResponse
+}
+
+// NewAPIError returns an APIError with the given status, code and message.
+func NewAPIError(status int, code ErrorCode, message string) *APIError {
+	return &APIError{
+		Status: status,
+		ErrorResponse: ErrorResponse{
+			Response: codersdk.Response{Message: message},
+			Code:     code,
+		},
+	}
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %s", e.Message, e.Detail)
+	}
+	return e.Message
+}
+
+// WriteError writes err as an ErrorResponse, picking the status from the kind
+// of error so handlers don't each pick their own:
+//   - An APIError is written as is.
+//   - Errors that Is404Error matches are a 404 that's identical to
+//     ResourceNotFound.
+//   - Validation errors are a 400 with the standard validations.
+//   - Bodies over the limit of http.MaxBytesReader are a 413.
+//   - context.DeadlineExceeded is a 504.
+//   - Any other error is a 500, like InternalServerError.
+//
+// Use WriteErrorCtx when the request context is at hand.
+func WriteError(rw http.ResponseWriter, err error) {
+	WriteErrorCtx(context.Background(), rw, err)
+}
+
+// WriteErrorCtx is like WriteError, but writes with ctx like Write does, so the
+// response keeps the request's trace span, negotiated codec and route label.
+func WriteErrorCtx(ctx context.Context, rw http.ResponseWriter, err error) {
+	status, response := mapError(err)
+	Write(ctx, rw, status, response)
+}
+
+func mapError(err error) (int, ErrorResponse) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status, apiErr.ErrorResponse
+	}
+	if Is404Error(err) {
+		return http.StatusNotFound, ErrorResponse{
+			Response: ResourceNotFoundResponse,
+			Code:     ErrorCodeNotFound,
+		}
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		validations := make([]codersdk.ValidationError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			validations = append(validations, toValidationError(fe, nil, nil))
+		}
+		return http.StatusBadRequest, ErrorResponse{
+			Response: codersdk.Response{
+				Message:     "Validation failed.",
+				Validations: validations,
+			},
+			Code: ErrorCodeValidationFailed,
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge, ErrorResponse{
+			Response: codersdk.Response{
+				Message: "Request body is too large.",
+				Detail:  fmt.Sprintf("The request body must not exceed %d bytes.", maxBytesErr.Limit),
+			},
+			Code: ErrorCodePayloadTooLarge,
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, ErrorResponse{
+			Response: codersdk.Response{
+				Message: "The request timed out.",
+				Detail:  err.Error(),
+			},
+			Code: ErrorCodeTimeout,
+		}
+	}
+
+	var detail string
+	if err != nil {
+		detail = err.Error()
+	}
+	return http.StatusInternalServerError, ErrorResponse{
+		Response: codersdk.Response{
+			Message: "An internal server error occurred.",
+			Detail:  detail,
+		},
+		Code: ErrorCodeInternal,
+	}
+}
@@ -0,0 +1,99 @@
+package httpapi_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, err error) (int, httpapi.ErrorResponse) {
+		rw := httptest.NewRecorder()
+		httpapi.WriteError(rw, err)
+		var resp httpapi.ErrorResponse
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return rw.Code, resp
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		status, resp := write(t, xerrors.Errorf("get workspace: %w", sql.ErrNoRows))
+		require.Equal(t, http.StatusNotFound, status)
+		require.Equal(t, httpapi.ErrorCodeNotFound, resp.Code)
+		require.Equal(t, httpapi.ResourceNotFoundResponse, resp.Response)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+		status, resp := write(t, xerrors.Errorf("query: %w", context.DeadlineExceeded))
+		require.Equal(t, http.StatusGatewayTimeout, status)
+		require.Equal(t, httpapi.ErrorCodeTimeout, resp.Code)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+		type request struct {
+			Name string `json:"name" validate:"required"`
+		}
+		err := httpapi.Validate.Struct(request{})
+		require.Error(t, err)
+		status, resp := write(t, xerrors.Errorf("validate: %w", err))
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, httpapi.ErrorCodeValidationFailed, resp.Code)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "name", resp.Validations[0].Field)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		status, resp := write(t, &http.MaxBytesError{Limit: 1024})
+		require.Equal(t, http.StatusRequestEntityTooLarge, status)
+		require.Equal(t, httpapi.ErrorCodePayloadTooLarge, resp.Code)
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		t.Parallel()
+		apiErr := httpapi.NewAPIError(http.StatusConflict, httpapi.ErrorCodeConflict, "Some templates couldn't be archived.")
+		apiErr.DocumentationURL = "https://coder.com/docs/templates"
+		apiErr.Errors = []httpapi.ErrorResponse{{
+			Code:     httpapi.ErrorCodeInvalidRequest,
+			Response: codersdk.Response{Message: "Template \"dev\" has running workspaces."},
+		}}
+		status, resp := write(t, xerrors.Errorf("archive: %w", apiErr))
+		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, apiErr.ErrorResponse, resp)
+	})
+
+	t.Run("Internal", func(t *testing.T) {
+		t.Parallel()
+		status, resp := write(t, xerrors.New("connection reset"))
+		require.Equal(t, http.StatusInternalServerError, status)
+		require.Equal(t, httpapi.ErrorCodeInternal, resp.Code)
+		require.Equal(t, "connection reset", resp.Detail)
+	})
+
+	t.Run("Ctx", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		ctx := httpapi.WithCodec(context.Background(), httpapi.MsgpackCodec)
+		httpapi.WriteErrorCtx(ctx, rw, httpapi.NewAPIError(http.StatusConflict, httpapi.ErrorCodeConflict, "Name is taken."))
+		require.Equal(t, http.StatusConflict, rw.Code)
+		require.Equal(t, httpapi.ContentTypeMsgpack, rw.Header().Get("Content-Type"))
+
+		var resp httpapi.ErrorResponse
+		require.NoError(t, httpapi.MsgpackCodec.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, httpapi.ErrorCodeConflict, resp.Code)
+		require.Equal(t, "Name is taken.", resp.Message)
+	})
+}
@@ -0,0 +1,264 @@
+package httpapi
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ReadForm decodes an application/x-www-form-urlencoded request body into the
+// struct pointed to by value, then validates it like Read. Fields are bound by
+// their `form:"name"` tag and converted to strings, bools, integers, floats,
+// UUIDs, RFC 3339 times, other encoding.TextUnmarshaler types or slices of
+// those. Empty values are treated as missing, so the field keeps its zero
+// value. Other content types are rejected with a 415.
+func ReadForm(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		Write(ctx, rw, http.StatusUnsupportedMediaType, codersdk.Response{
+			Message: "Request body must be form encoded.",
+			Detail:  fmt.Sprintf("Unsupported content type %q.", r.Header.Get("Content-Type")),
+		})
+		return false
+	}
+	err := r.ParseForm()
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be a valid form.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	ptr := reflect.ValueOf(value)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error decoding form.",
+			Detail:  "value must be a pointer to a struct",
+		})
+		return false
+	}
+	validations := bindValues(r.PostForm, ptr.Elem(), "form")
+	if len(validations) > 0 {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid form values.",
+			Validations: validations,
+		})
+		return false
+	}
+	return validateRequest(ctx, rw, r, value)
+}
+
+// ReadQuery binds the request's query params into the struct pointed to by
+// value, then validates it like Read. Fields are bound by their
+// `query:"name"` tag and converted like ReadForm. Repeated params, such as
+// "?status=running&status=stopped", bind to slice fields, and a single value
+// binds to a one element slice. Use `dive` to validate each element.
+//
+// Embedded structs are bound too, so common params like PaginationParams can
+// be shared between endpoints.
+func ReadQuery(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	ptr := reflect.ValueOf(value)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error decoding query parameters.",
+			Detail:  "value must be a pointer to a struct",
+		})
+		return false
+	}
+	validations := bindValues(r.URL.Query(), ptr.Elem(), "query")
+	if len(validations) > 0 {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: validations,
+		})
+		return false
+	}
+	return validateRequest(ctx, rw, r, value)
+}
+
+// ParseQuery is like ReadQuery, but returns the bound value of type T, which
+// must be a struct.
+//
+//	type listWorkspacesQuery struct {
+//		httpapi.PaginationParams
+//		Owner  string    `query:"owner"`
+//		Status []string  `query:"status" validate:"dive,oneof=running stopped"`
+//		Since  time.Time `query:"since"`
+//	}
+//
+//	query, ok := httpapi.ParseQuery[listWorkspacesQuery](rw, r)
+//	if !ok {
+//		return
+//	}
+func ParseQuery[T any](rw http.ResponseWriter, r *http.Request) (T, bool) {
+	var value T
+	ok := ReadQuery(r.Context(), rw, r, &value)
+	return value, ok
+}
+
+// ReadQueryJSON decodes the JSON object in the named query param, e.g.
+// "?filter={...}", into value, then validates it like Read. A missing param
+// leaves value as is, so it's still validated.
+func ReadQueryJSON(rw http.ResponseWriter, r *http.Request, param string, value interface{}) bool {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	if raw := r.URL.Query().Get(param); raw != "" {
+		err := json.Unmarshal([]byte(raw), value)
+		if err != nil {
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query parameters have invalid values.",
+				Validations: []codersdk.ValidationError{{
+					Field:  param,
+					Detail: fmt.Sprintf("Query param %q must be valid JSON: %s", param, err.Error()),
+					Code:   string(ErrorCodeInvalidJSON),
+				}},
+			})
+			return false
+		}
+	}
+	return validateRequest(ctx, rw, r, value)
+}
+
+// bindValues sets the fields of v carrying the struct tag from values,
+// returning an error for each value that couldn't be converted.
+func bindValues(values url.Values, v reflect.Value, tag string) []codersdk.ValidationError {
+	var validations []codersdk.ValidationError
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validations = append(validations, bindValues(values, v.Field(i), tag)...)
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 || (len(raw) == 1 && raw[0] == "") {
+			continue
+		}
+
+		fv := v.Field(i)
+		var err error
+		if fv.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fv.Type(), len(raw), len(raw))
+			for j, s := range raw {
+				err = setFormValue(slice.Index(j), s)
+				if err != nil {
+					err = xerrors.Errorf("element %d %w", j, err)
+					break
+				}
+			}
+			if err == nil {
+				fv.Set(slice)
+			}
+		} else {
+			err = setFormValue(fv, raw[0])
+		}
+		if err != nil {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  name,
+				Detail: fmt.Sprintf("%s value %q is invalid: %s", valueKinds[tag], name, err.Error()),
+				Code:   string(ErrorCodeInvalidType),
+			})
+		}
+	}
+	return validations
+}
+
+// valueKinds describe the source of values bound with each tag.
+var valueKinds = map[string]string{
+	"form":  "Form",
+	"query": "Query param",
+}
+
+// setFormValue converts s to the kind of v and sets it.
+func setFormValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		err := setFormValue(ptr.Elem(), s)
+		if err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch v.Type() {
+	case reflect.TypeOf(uuid.UUID{}):
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return xerrors.New("must be a valid UUID")
+		}
+		v.Set(reflect.ValueOf(id))
+		return nil
+	case reflect.TypeOf(time.Time{}):
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return xerrors.New("must be a valid RFC 3339 timestamp")
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err := u.UnmarshalText([]byte(s))
+		if err != nil {
+			return xerrors.Errorf("must be valid: %w", err)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return xerrors.New("must be a valid boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return xerrors.New("must be a valid integer")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return xerrors.New("must be a valid positive integer")
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return xerrors.New("must be a valid number")
+		}
+		v.SetFloat(f)
+	default:
+		return xerrors.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadForm(t *testing.T) {
+	t.Parallel()
+
+	type tokenRequest struct {
+		GrantType string   `form:"grant_type" validate:"required"`
+		ExpiresIn int      `form:"expires_in"`
+		Refresh   bool     `form:"refresh"`
+		Scopes    []string `form:"scope"`
+	}
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := newRequest("application/x-www-form-urlencoded", "grant_type=authorization_code&expires_in=3600&refresh=true&scope=read&scope=write")
+
+		var v tokenRequest
+		require.True(t, httpapi.ReadForm(context.Background(), rw, r, &v))
+		require.Equal(t, tokenRequest{
+			GrantType: "authorization_code",
+			ExpiresIn: 3600,
+			Refresh:   true,
+			Scopes:    []string{"read", "write"},
+		}, v)
+	})
+
+	t.Run("ConversionFailure", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := newRequest("application/x-www-form-urlencoded; charset=utf-8", "grant_type=code&expires_in=soon")
+
+		var v tokenRequest
+		require.False(t, httpapi.ReadForm(context.Background(), rw, r, &v))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "expires_in", resp.Validations[0].Field)
+	})
+
+	t.Run("ValidationFailure", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := newRequest("application/x-www-form-urlencoded", "expires_in=10")
+
+		var v tokenRequest
+		require.False(t, httpapi.ReadForm(context.Background(), rw, r, &v))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("WrongContentType", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := newRequest("application/json", `{"grant_type":"code"}`)
+
+		var v tokenRequest
+		require.False(t, httpapi.ReadForm(context.Background(), rw, r, &v))
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+}
+
+func TestReadQuery(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		Status []string `query:"status" validate:"dive,oneof=running stopped failed"`
+		Owner  string   `query:"owner"`
+		Builds []int    `query:"build" validate:"dive,gt=0"`
+	}
+	read := func(t *testing.T, query string) (*httptest.ResponseRecorder, filter, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/workspaces?"+query, nil)
+		var v filter
+		ok := httpapi.ReadQuery(context.Background(), rw, r, &v)
+		return rw, v, ok
+	}
+
+	t.Run("Multiple", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, "status=running&status=stopped&build=1&build=2&owner=me")
+		require.True(t, ok)
+		require.Equal(t, filter{
+			Status: []string{"running", "stopped"},
+			Owner:  "me",
+			Builds: []int{1, 2},
+		}, v)
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, "status=failed")
+		require.True(t, ok)
+		require.Equal(t, []string{"failed"}, v.Status)
+		require.Nil(t, v.Builds)
+	})
+
+	t.Run("ConversionFailure", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "build=1&build=two")
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "build", resp.Validations[0].Field)
+		require.Contains(t, resp.Validations[0].Detail, "element 1 must be a valid integer")
+		require.Equal(t, "invalid_type", resp.Validations[0].Code)
+	})
+
+	t.Run("ElementValidation", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "status=running&status=deleted")
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "status[1]", resp.Validations[0].Field)
+	})
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	type listQuery struct {
+		httpapi.PaginationParams
+		Owner    uuid.UUID `query:"owner"`
+		Deleted  bool      `query:"deleted"`
+		Since    time.Time `query:"since"`
+		Until    time.Time `query:"until" validate:"omitempty,gtfield=Since"`
+		Statuses []string  `query:"status"`
+	}
+	parse := func(t *testing.T, query string) (*httptest.ResponseRecorder, listQuery, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/workspaces?"+query, nil)
+		v, ok := httpapi.ParseQuery[listQuery](rw, r)
+		return rw, v, ok
+	}
+	decode := func(t *testing.T, rw *httptest.ResponseRecorder) codersdk.Response {
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		owner := uuid.New()
+		afterID := uuid.New()
+		_, v, ok := parse(t, url.Values{
+			"owner":    {owner.String()},
+			"deleted":  {"true"},
+			"since":    {"2024-01-01T00:00:00Z"},
+			"until":    {"2024-02-01T00:00:00Z"},
+			"status":   {"running", "stopped"},
+			"after_id": {afterID.String()},
+			"limit":    {"25"},
+			"offset":   {"50"},
+		}.Encode())
+		require.True(t, ok)
+		require.Equal(t, owner, v.Owner)
+		require.True(t, v.Deleted)
+		require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), v.Since)
+		require.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), v.Until)
+		require.Equal(t, []string{"running", "stopped"}, v.Statuses)
+		require.Equal(t, codersdk.Pagination{AfterID: afterID, Limit: 25, Offset: 50}, v.Pagination())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := parse(t, "owner=&limit=&since=")
+		require.True(t, ok)
+		require.Equal(t, listQuery{}, v)
+	})
+
+	t.Run("InvalidValues", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := parse(t, "owner=bogus&since=yesterday")
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := decode(t, rw)
+		require.Equal(t, "Query parameters have invalid values.", resp.Message)
+		require.Len(t, resp.Validations, 2)
+		require.Equal(t, "owner", resp.Validations[0].Field)
+		require.Contains(t, resp.Validations[0].Detail, "must be a valid UUID")
+		require.Equal(t, "since", resp.Validations[1].Field)
+		require.Contains(t, resp.Validations[1].Detail, "must be a valid RFC 3339 timestamp")
+	})
+
+	t.Run("InvalidPagination", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := parse(t, "limit=-1&after_id=nope")
+		require.False(t, ok)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "after_id", resp.Validations[0].Field)
+
+		rw, _, ok = parse(t, "limit=-1")
+		require.False(t, ok)
+		resp = decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "limit", resp.Validations[0].Field)
+	})
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := parse(t, "since=2024-02-01T00:00:00Z&until=2024-01-01T00:00:00Z")
+		require.False(t, ok)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "until", resp.Validations[0].Field)
+		require.Contains(t, resp.Validations[0].Detail, "must be after since")
+	})
+}
+
+func TestReadQueryJSON(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		Owner  string   `json:"owner" validate:"required"`
+		Status []string `json:"status"`
+	}
+	read := func(t *testing.T, query string) (*httptest.ResponseRecorder, filter, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/workspaces?"+query, nil)
+		var v filter
+		ok := httpapi.ReadQueryJSON(rw, r, "filter", &v)
+		return rw, v, ok
+	}
+	decode := func(t *testing.T, rw *httptest.ResponseRecorder) codersdk.Response {
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, "filter="+url.QueryEscape(`{"owner":"me","status":["running"]}`))
+		require.True(t, ok)
+		require.Equal(t, filter{Owner: "me", Status: []string{"running"}}, v)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "filter="+url.QueryEscape(`{"owner":`))
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "filter", resp.Validations[0].Field)
+		require.Contains(t, resp.Validations[0].Detail, "must be valid JSON")
+		require.Equal(t, "invalid_json", resp.Validations[0].Code)
+	})
+
+	t.Run("ValidationFailure", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "filter="+url.QueryEscape(`{"status":["running"]}`))
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := decode(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "owner", resp.Validations[0].Field)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "")
+		require.False(t, ok)
+		require.Equal(t, "owner", decode(t, rw).Validations[0].Field)
+	})
+}
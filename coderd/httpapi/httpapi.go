@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"database/sql"
@@ -8,9 +9,14 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -34,6 +40,16 @@ func init() {
 		if name == "-" {
 			return ""
 		}
+		// Structs bound by ReadQuery or ReadForm may only be tagged for those.
+		for _, tag := range []string{"query", "form"} {
+			if name != "" {
+				break
+			}
+			name = strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+		}
 		return name
 	})
 
@@ -96,6 +112,15 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	for tag, v := range validations {
+		err = Validate.RegisterValidation(tag, v.fn)
+		if err != nil {
+			panic(err)
+		}
+	}
+	registerOptionalTypes()
+	registerTranslations()
 }
 
 // Is404Error returns true if the given error should return a 404 status code.
@@ -149,6 +174,86 @@ func RouteNotFound(rw http.ResponseWriter) {
 	})
 }
 
+// MethodNotAllowed responds to a request for a route that exists, but not
+// with the request's method. Routers should use it instead of their plain text
+// default, so every error has the standard body.
+func MethodNotAllowed(rw http.ResponseWriter, r *http.Request) {
+	Write(r.Context(), rw, http.StatusMethodNotAllowed, codersdk.Response{
+		Message: fmt.Sprintf("Method %s is not allowed for this route.", r.Method),
+	})
+}
+
+// WriteDeleted responds to a successful DELETE with a 204 and no body.
+func WriteDeleted(rw http.ResponseWriter) {
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// AlreadyDeleted responds to a DELETE for a resource that no longer exists.
+// Deletes are idempotent, so a retried delete gets the same 204 as the first
+// one instead of a 404. Other methods should still use ResourceNotFound.
+func AlreadyDeleted(rw http.ResponseWriter) {
+	WriteDeleted(rw)
+}
+
+// WriteRateLimited writes a 429 along with the standard rate limit headers.
+// It's intended for handlers that throttle requests based on their own logic,
+// rather than via the rate limit middleware.
+func WriteRateLimited(rw http.ResponseWriter, limit, remaining int, reset time.Time) {
+	retryAfter := int(math.Ceil(time.Until(reset).Seconds()))
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+
+	h := rw.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	h.Set("Retry-After", strconv.Itoa(retryAfter))
+	Write(context.Background(), rw, http.StatusTooManyRequests, codersdk.Response{
+		Message: "You've been rate limited.",
+		Detail:  fmt.Sprintf("Try again in %d seconds.", retryAfter),
+	})
+}
+
+// WriteAccepted writes a 202 for a long-running operation, with a Location
+// header pointing at the endpoint clients should poll for its status.
+func WriteAccepted(rw http.ResponseWriter, statusURL string, response codersdk.Response) {
+	rw.Header().Set("Location", statusURL)
+	Write(context.Background(), rw, http.StatusAccepted, response)
+}
+
+// FieldErrorsResponse is a validation failure that, in addition to the
+// standard validations, maps each field to its error for form libraries that
+// bind errors by field name.
+type FieldErrorsResponse struct {
+	codersdk.Response
+	FieldErrors map[string]string `json:"field_errors"`
+}
+
+// WriteFieldErrors writes a 400 for the provided field to error message
+// mapping, as both the standard validations and a flat field_errors object.
+func WriteFieldErrors(rw http.ResponseWriter, fields map[string]string) {
+	validations := make([]codersdk.ValidationError, 0, len(fields))
+	for field, detail := range fields {
+		validations = append(validations, codersdk.ValidationError{
+			Field:  field,
+			Detail: detail,
+			Code:   string(ErrorCodeValidationFailed),
+		})
+	}
+	sort.Slice(validations, func(i, j int) bool {
+		return validations[i].Field < validations[j].Field
+	})
+
+	Write(context.Background(), rw, http.StatusBadRequest, FieldErrorsResponse{
+		Response: codersdk.Response{
+			Message:     "Validation failed.",
+			Validations: validations,
+		},
+		FieldErrors: fields,
+	})
+}
+
 // Write outputs a standardized format to an HTTP response body. ctx is used for
 // tracing and can be nil for tracing to be disabled. Tracing this function is
 // helpful because JSON marshaling can sometimes take a non-insignificant amount
@@ -156,7 +261,18 @@ func RouteNotFound(rw http.ResponseWriter) {
 // data a bit more since we have access to the actual interface{} we're
 // marshaling, such as the number of elements in an array, which could help us
 // spot routes that need to be paginated.
+//
+// Responses are encoded with the codec of ctx if one was negotiated, see
+// WithCodec.
 func Write(ctx context.Context, rw http.ResponseWriter, status int, response interface{}) {
+	if codec := CodecFromContext(ctx); codec != JSONCodec {
+		_, span := tracing.StartSpan(ctx)
+		defer span.End()
+
+		writeCodec(ctx, rw, status, response, codec)
+		return
+	}
+
 	// Pretty up JSON when testing.
 	if flag.Lookup("test.v") != nil {
 		WriteIndent(ctx, rw, status, response)
@@ -166,73 +282,348 @@ func Write(ctx context.Context, rw http.ResponseWriter, status int, response int
 	_, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
-	rw.WriteHeader(status)
+	writeJSON(ctx, rw, status, response, true, false)
+}
 
-	enc := json.NewEncoder(rw)
-	enc.SetEscapeHTML(true)
+// ResponseDecorator is invoked by WriteRequest to wrap or augment every
+// response before it's encoded, e.g. to inject server metadata without
+// touching each handler. It defaults to returning the response unchanged and
+// should only be replaced during init.
+var ResponseDecorator = func(_ *http.Request, _ int, response interface{}) interface{} {
+	return response
+}
 
-	// We can't really do much about these errors, it's probably due to a
-	// dropped connection.
-	_ = enc.Encode(response)
+// WriteRequest is like Write, but passes the response through
+// ResponseDecorator first. The request context is used for tracing.
+func WriteRequest(rw http.ResponseWriter, r *http.Request, status int, response interface{}) {
+	Write(r.Context(), rw, status, ResponseDecorator(r, status, response))
+}
+
+// WriteRaw is like Write, but doesn't escape '<', '>' and '&' in strings.
+// Write escapes them so responses are safe to embed in HTML, which mangles
+// URLs and code snippets for API-to-API consumers. Only use this for responses
+// that are never rendered by a browser.
+func WriteRaw(ctx context.Context, rw http.ResponseWriter, status int, response interface{}) {
+	_, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	writeJSON(ctx, rw, status, response, false, flag.Lookup("test.v") != nil)
 }
 
 func WriteIndent(ctx context.Context, rw http.ResponseWriter, status int, response interface{}) {
 	_, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
-	rw.WriteHeader(status)
+	writeJSON(ctx, rw, status, response, true, true)
+}
+
+// LargeResponseThreshold is the encoded size in bytes above which
+// OnLargeResponse is called for a response. Unexpectedly large responses are
+// often caused by a missing filter or pagination. Zero disables the check.
+// Both should only be set during init.
+var LargeResponseThreshold int
+
+// OnLargeResponse is called with the encoded size of every response larger
+// than LargeResponseThreshold, e.g. to log or record a metric.
+var OnLargeResponse func(size int)
+
+// OnEncodeError is called when a response can't be encoded as JSON, e.g.
+// because it contains a channel or func, or is an error value that would be
+// sent as {}. The client receives a generic 500 instead. It should only be set during init.
+var OnEncodeError func(err error)
+
+func writeJSON(ctx context.Context, rw http.ResponseWriter, status int, response interface{}, escapeHTML bool, indent bool) {
+	writeJSONAs(ctx, rw, status, "application/json; charset=utf-8", response, escapeHTML, indent)
+}
+
+// writeJSONAs is like writeJSON, but with a JSON based content type such as
+// JSONAPIContentType.
+func writeJSONAs(ctx context.Context, rw http.ResponseWriter, status int, contentType string, response interface{}, escapeHTML bool, indent bool) {
+	start := time.Now()
+	body, err := encodeJSON(response, escapeHTML, indent)
+	encodeLatency := time.Since(start)
+	if err != nil {
+		if OnEncodeError != nil {
+			OnEncodeError(err)
+		}
+		// Nothing has been written yet, so the status can still change.
+		status = http.StatusInternalServerError
+		body, _ = encodeJSON(codersdk.Response{Message: "internal server error"}, escapeHTML, indent)
+	}
+	writeBody(rw, status, contentType, body)
+	observeResponse(ctx, status, len(body), encodeLatency)
+}
+
+// encodeJSON encodes response like Write does, rejecting errors that encode
+// to {}.
+func encodeJSON(response interface{}, escapeHTML bool, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if indent {
+		enc.SetIndent("", "\t")
+	}
+	err := enc.Encode(response)
+	if err == nil {
+		err = checkErrorResponse(response, buf.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	enc := json.NewEncoder(rw)
-	enc.SetEscapeHTML(true)
-	enc.SetIndent("", "\t")
+// writeBody writes an encoded response body.
+func writeBody(rw http.ResponseWriter, status int, contentType string, body []byte) {
+	if LargeResponseThreshold > 0 && len(body) > LargeResponseThreshold && OnLargeResponse != nil {
+		OnLargeResponse(len(body))
+	}
 
+	rw.Header().Set("Content-Type", contentType)
+	// The body is fully buffered, so send its length rather than relying on
+	// chunked encoding.
+	rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	rw.WriteHeader(status)
 	// We can't really do much about these errors, it's probably due to a
 	// dropped connection.
-	_ = enc.Encode(response)
+	_, _ = rw.Write(body)
+}
+
+// checkErrorResponse returns an error if response is an error value that
+// encoded to an empty object, as errors with only unexported fields do. This
+// happens when an error is written instead of the data, and would otherwise
+// be sent as a successful {}.
+func checkErrorResponse(response interface{}, encoded []byte) error {
+	if _, ok := response.(error); !ok {
+		return nil
+	}
+	if !bytes.Equal(bytes.TrimSpace(encoded), []byte("{}")) {
+		return nil
+	}
+	return xerrors.Errorf("response is an error value of type %T, which encodes to an empty object", response)
 }
 
 // Read decodes JSON from the HTTP request into the value provided. It uses
 // go-validator to validate the incoming request body. ctx is used for tracing
 // and can be nil. Although tracing this function isn't likely too helpful, it
 // was done to be consistent with Write.
+//
+// Bodies with a msgpack or protobuf Content-Type are decoded with that codec
+// instead if the route accepts it, see WithAcceptedCodecs.
 func Read(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	err := json.NewDecoder(r.Body).Decode(value)
+	if !readBody(ctx, rw, r, value) {
+		return false
+	}
+	return validateRequest(ctx, rw, r, value)
+}
+
+// readBody decodes the request body into value without validating it,
+// writing an error response and returning false if it can't be. It handles
+// the codec, charset, size limit and progress reporting for everything that
+// reads request bodies like Read.
+func readBody(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	if codec, ok := requestCodec(r); ok {
+		return readCodec(ctx, rw, r, value, codec)
+	}
+
+	body, done := withReadProgress(ctx, r.Body)
+	body, err := transcodeBody(r, body)
 	if err != nil {
-		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "Request body must be valid JSON.",
+		Write(ctx, rw, http.StatusUnsupportedMediaType, codersdk.Response{
+			Message: "Unsupported request body charset.",
 			Detail:  err.Error(),
 		})
 		return false
 	}
-	err = Validate.Struct(value)
-	var validationErrors validator.ValidationErrors
-	if errors.As(err, &validationErrors) {
-		apiErrors := make([]codersdk.ValidationError, 0, len(validationErrors))
-		for _, validationError := range validationErrors {
-			apiErrors = append(apiErrors, codersdk.ValidationError{
-				Field:  validationError.Field(),
-				Detail: fmt.Sprintf("Validation failed for tag %q with value: \"%v\"", validationError.Tag(), validationError.Value()),
-			})
+	// The body is decoded straight from a pooled buffer, so there's no
+	// decoder buffer to allocate, and its bytes are still there to locate
+	// type errors in.
+	buf := pooledBodyBuffer()
+	if body != nil {
+		_, err = buf.ReadFrom(body)
+	}
+	var validations []codersdk.ValidationError
+	if err == nil {
+		data := bytes.TrimPrefix(buf.Bytes(), utf8BOM)
+		err = unmarshalFirst(data, value)
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			validations = append(validations, typeErrorValidation(typeErr, data))
+			observeDecodeFailures(ctx, reflect.TypeOf(value), validations, ErrorCodeInvalidType)
+		}
+	}
+	releaseBodyBuffer(buf)
+	done()
+	if err != nil {
+		// The body may be limited by ReadLimited or a middleware.
+		if writeBodyTooLarge(ctx, rw, err) {
+			return false
 		}
 		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message:     "Validation failed.",
-			Validations: apiErrors,
+			Message:     "Request body must be valid JSON.",
+			Detail:      err.Error(),
+			Validations: validations,
 		})
 		return false
 	}
-	if err != nil {
+	return true
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// skipBOM strips a leading UTF-8 byte order mark from r. Some Windows clients
+// prepend one to JSON bodies, which encoding/json rejects. Leading whitespace
+// after it is already skipped by the decoder.
+func skipBOM(r io.Reader) io.Reader {
+	if r == nil {
+		return r
+	}
+	br := bufio.NewReader(r)
+	discardBOM(br)
+	return br
+}
+
+func discardBOM(br *bufio.Reader) {
+	prefix, _ := br.Peek(len(utf8BOM))
+	if bytes.Equal(prefix, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+}
+
+// unmarshalFirst decodes the first JSON value in data into value, ignoring
+// anything after it like a json.Decoder does.
+func unmarshalFirst(data []byte, value interface{}) error {
+	err := json.Unmarshal(data, value)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Unmarshal checks all of data before decoding any of it, so value
+		// is untouched. A decoder stops after the first value, and reports
+		// a body that's actually malformed the way Read always has.
+		return json.NewDecoder(bytes.NewReader(data)).Decode(value)
+	}
+	return err
+}
+
+// maxPooledBodySize is the largest body buffer returned to bodyBufferPool, so
+// a single large request doesn't pin memory.
+const maxPooledBodySize = 64 << 10
+
+// bodyBufferPool reuses the buffers Read reads request bodies into, which
+// are otherwise allocated for every request.
+var bodyBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// pooledBodyBuffer returns an empty buffer from bodyBufferPool, which must be
+// returned with releaseBodyBuffer.
+func pooledBodyBuffer() *bytes.Buffer {
+	buf, _ := bodyBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// releaseBodyBuffer returns buf to the pool unless it grew too large.
+func releaseBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBodySize {
+		return
+	}
+	buf.Reset()
+	bodyBufferPool.Put(buf)
+}
+
+// ReadCtx is like Read, but uses the request context. Context-aware
+// validations registered with RegisterValidationCtx, such as those doing I/O,
+// observe the request's cancellation and deadline.
+func ReadCtx(rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	return Read(r.Context(), rw, r, value)
+}
+
+// ValidateOnly decodes and validates the request body like Read, but instead
+// of handing the value off it responds with 200 on success. It's intended for
+// dry-run endpoints that report validation errors without side effects.
+// Invalid payloads get the same 400 as Read writes, so a dry run fails exactly
+// like the real request would.
+func ValidateOnly(rw http.ResponseWriter, r *http.Request, newValue func() any) bool {
+	if !Read(r.Context(), rw, r, newValue()) {
+		return false
+	}
+	Write(r.Context(), rw, http.StatusOK, codersdk.Response{
+		Message: "valid",
+	})
+	return true
+}
+
+// Validatable can be implemented by request types with validation rules too
+// complex for struct tags, such as cross-field business rules. Read calls
+// Validate after tag validation and reports any returned errors alongside
+// the tag validation errors, in the same 400 response, so clients see one
+// status for invalid requests however the rules are checked.
+type Validatable interface {
+	Validate() []codersdk.ValidationError
+}
+
+// Normalizer can be implemented by request types to canonicalize fields, such
+// as lowercasing the domain of an email address with NormalizeEmail. Read calls
+// Normalize once validation succeeds, so it can assume the values are valid.
+type Normalizer interface {
+	Normalize()
+}
+
+// validateRequest runs go-validator against a decoded request body and writes
+// the standard validation error response on failure. ctx is passed to
+// context-aware validations, and errors are described in the locale r asks
+// for.
+func validateRequest(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var apiErrors []codersdk.ValidationError
+	err := Validate.StructCtx(ctx, value)
+	trans := requestTranslator(r)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, validationError := range validationErrors {
+			apiError := toValidationError(validationError, reflect.TypeOf(value), trans)
+			observeValidationFailure(ctx, apiError.Field, apiError.Code)
+			apiErrors = append(apiErrors, apiError)
+		}
+	} else if err != nil {
 		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error validating request body payload.",
 			Detail:  err.Error(),
 		})
 		return false
 	}
-	return true
+	if v, ok := value.(Validatable); ok {
+		custom := v.Validate()
+		observeValidationFailures(ctx, custom, ErrorCodeValidationFailed)
+		apiErrors = append(apiErrors, custom...)
+	}
+	if len(apiErrors) == 0 {
+		if n, ok := value.(Normalizer); ok {
+			n.Normalize()
+		}
+		return true
+	}
+
+	// Sort so identical requests produce identical responses. Details are
+	// localized, so they aren't sorted by.
+	sort.SliceStable(apiErrors, func(i, j int) bool {
+		if apiErrors[i].Field != apiErrors[j].Field {
+			return apiErrors[i].Field < apiErrors[j].Field
+		}
+		return apiErrors[i].Code < apiErrors[j].Code
+	})
+	Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		Message:     "Validation failed.",
+		Validations: apiErrors,
+	})
+	return false
 }
 
 const websocketCloseMaxLen = 123
@@ -253,17 +644,28 @@ func WebsocketCloseSprintf(format string, vars ...any) string {
 	return msg
 }
 
+// ServerSentEventSender starts a text/event-stream response, returning a
+// function to send events and a channel that's closed once the stream ends,
+// when the client disconnects or a write fails. Events are encoded as JSON like
+// Write and flushed as they're sent, and pings are sent while idle so proxies
+// don't time out the connection.
+//
+// The response is started right away, so proxies and clients see the stream
+// open before the first event. An error is returned without writing anything
+// if rw can't be flushed, so the caller can still write an error response.
 func ServerSentEventSender(rw http.ResponseWriter, r *http.Request) (sendEvent func(ctx context.Context, sse codersdk.ServerSentEvent) error, closed chan struct{}, err error) {
+	f, ok := responseFlusher(rw)
+	if !ok {
+		return nil, nil, xerrors.Errorf("%T does not support flushing", rw)
+	}
+
 	h := rw.Header()
 	h.Set("Content-Type", "text/event-stream")
 	h.Set("Cache-Control", "no-cache")
 	h.Set("Connection", "keep-alive")
 	h.Set("X-Accel-Buffering", "no")
-
-	f, ok := rw.(http.Flusher)
-	if !ok {
-		panic("http.ResponseWriter is not http.Flusher")
-	}
+	rw.WriteHeader(http.StatusOK)
+	f.Flush()
 
 	closed = make(chan struct{})
 	type sseEvent struct {
@@ -358,3 +760,18 @@ func ServerSentEventSender(rw http.ResponseWriter, r *http.Request) (sendEvent f
 
 	return sendEvent, closed, nil
 }
+
+// responseFlusher returns the http.Flusher of rw, looking through middleware
+// that wrap it and provide Unwrap for http.ResponseController.
+func responseFlusher(rw http.ResponseWriter) (http.Flusher, bool) {
+	for {
+		if f, ok := rw.(http.Flusher); ok {
+			return f, true
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		rw = u.Unwrap()
+	}
+}
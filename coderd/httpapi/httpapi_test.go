@@ -1,15 +1,20 @@
 package httpapi_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
@@ -52,6 +57,104 @@ func TestInternalServerError(t *testing.T) {
 	})
 }
 
+func TestWriteDeleted(t *testing.T) {
+	t.Parallel()
+
+	for name, write := range map[string]func(http.ResponseWriter){
+		"Deleted":        httpapi.WriteDeleted,
+		"AlreadyDeleted": httpapi.AlreadyDeleted,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			write(rw)
+			require.Equal(t, http.StatusNoContent, rw.Code)
+			require.Empty(t, rw.Body.Bytes())
+		})
+	}
+}
+
+func TestWriteRateLimited(t *testing.T) {
+	t.Parallel()
+
+	reset := time.Now().Add(30 * time.Second)
+	rw := httptest.NewRecorder()
+	httpapi.WriteRateLimited(rw, 100, 0, reset)
+
+	require.Equal(t, http.StatusTooManyRequests, rw.Code)
+	require.Equal(t, "100", rw.Header().Get("X-RateLimit-Limit"))
+	require.Equal(t, "0", rw.Header().Get("X-RateLimit-Remaining"))
+	require.Equal(t, strconv.FormatInt(reset.Unix(), 10), rw.Header().Get("X-RateLimit-Reset"))
+	retryAfter, err := strconv.Atoi(rw.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.InDelta(t, 30, retryAfter, 1)
+
+	var resp codersdk.Response
+	err = json.NewDecoder(rw.Body).Decode(&resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Message)
+}
+
+func TestWriteAccepted(t *testing.T) {
+	t.Parallel()
+
+	rw := httptest.NewRecorder()
+	httpapi.WriteAccepted(rw, "/api/v2/workspacebuilds/123", codersdk.Response{
+		Message: "Build started.",
+	})
+
+	require.Equal(t, http.StatusAccepted, rw.Code)
+	require.Equal(t, "/api/v2/workspacebuilds/123", rw.Header().Get("Location"))
+	var resp codersdk.Response
+	err := json.NewDecoder(rw.Body).Decode(&resp)
+	require.NoError(t, err)
+	require.Equal(t, "Build started.", resp.Message)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	r.NotFound(func(rw http.ResponseWriter, r *http.Request) { httpapi.RouteNotFound(rw) })
+	r.MethodNotAllowed(httpapi.MethodNotAllowed)
+	r.Get("/workspaces", func(rw http.ResponseWriter, r *http.Request) {})
+
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("DELETE", "/workspaces", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	var resp codersdk.Response
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+	require.Equal(t, "Method DELETE is not allowed for this route.", resp.Message)
+
+	rw = httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("GET", "/templates", nil))
+	require.Equal(t, http.StatusNotFound, rw.Code)
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+	require.Equal(t, "Route not found.", resp.Message)
+}
+
+func TestWriteFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]string{
+		"email":    "is required",
+		"username": "is taken",
+	}
+	rw := httptest.NewRecorder()
+	httpapi.WriteFieldErrors(rw, fields)
+	require.Equal(t, http.StatusBadRequest, rw.Code)
+
+	var resp httpapi.FieldErrorsResponse
+	err := json.NewDecoder(rw.Body).Decode(&resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Message)
+	require.Equal(t, fields, resp.FieldErrors)
+	require.Equal(t, []codersdk.ValidationError{
+		{Field: "email", Detail: "is required", Code: "validation_failed"},
+		{Field: "username", Detail: "is taken", Code: "validation_failed"},
+	}, resp.Validations)
+}
+
 func TestWrite(t *testing.T) {
 	t.Parallel()
 	t.Run("NoErrors", func(t *testing.T) {
@@ -69,6 +172,146 @@ func TestWrite(t *testing.T) {
 	})
 }
 
+func TestWriteRequest(t *testing.T) {
+	// ResponseDecorator is shared, so it must be swapped before any parallel
+	// test runs. No other test relies on it.
+	serverTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	original := httpapi.ResponseDecorator
+	httpapi.ResponseDecorator = func(r *http.Request, status int, response interface{}) interface{} {
+		return map[string]interface{}{
+			"server_time": serverTime,
+			"status":      status,
+			"response":    response,
+		}
+	}
+	t.Cleanup(func() {
+		httpapi.ResponseDecorator = original
+	})
+	t.Parallel()
+
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	httpapi.WriteRequest(rw, r, http.StatusOK, codersdk.Response{Message: "Wow."})
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	var got struct {
+		ServerTime time.Time         `json:"server_time"`
+		Status     int               `json:"status"`
+		Response   codersdk.Response `json:"response"`
+	}
+	err := json.NewDecoder(rw.Body).Decode(&got)
+	require.NoError(t, err)
+	require.True(t, serverTime.Equal(got.ServerTime))
+	require.Equal(t, http.StatusOK, got.Status)
+	require.Equal(t, "Wow.", got.Response.Message)
+}
+
+// nolint:paralleltest // Swaps the package-level large response hook.
+func TestLargeResponse(t *testing.T) {
+	var sizes []int
+	httpapi.LargeResponseThreshold = 1024
+	httpapi.OnLargeResponse = func(size int) {
+		sizes = append(sizes, size)
+	}
+	defer func() {
+		httpapi.LargeResponseThreshold = 0
+		httpapi.OnLargeResponse = nil
+	}()
+
+	rw := httptest.NewRecorder()
+	httpapi.Write(context.Background(), rw, http.StatusOK, codersdk.Response{Message: "Small."})
+	require.Empty(t, sizes)
+
+	rw = httptest.NewRecorder()
+	httpapi.Write(context.Background(), rw, http.StatusOK, codersdk.Response{Message: strings.Repeat("a", 2048)})
+	require.Equal(t, []int{rw.Body.Len()}, sizes)
+}
+
+// nolint:paralleltest // Swaps the package-level encode error hook.
+func TestWriteUnmarshalable(t *testing.T) {
+	var encodeErr error
+	httpapi.OnEncodeError = func(err error) {
+		encodeErr = err
+	}
+	defer func() {
+		httpapi.OnEncodeError = nil
+	}()
+
+	for _, tc := range []struct {
+		name     string
+		response interface{}
+	}{
+		{name: "ChannelField", response: struct {
+			Name string      `json:"name"`
+			Done chan string `json:"done"`
+		}{Name: "leaked", Done: make(chan string)}},
+		{name: "Channel", response: make(chan int)},
+		{name: "Func", response: func() {}},
+		// Errors usually only have unexported fields, so they'd be sent as {}.
+		{name: "Error", response: xerrors.New("database is down")},
+	} {
+		encodeErr = nil
+		rw := httptest.NewRecorder()
+		httpapi.Write(context.Background(), rw, http.StatusOK, tc.response)
+		require.Error(t, encodeErr, tc.name)
+		require.Equal(t, http.StatusInternalServerError, rw.Code, tc.name)
+		require.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"), tc.name)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp), tc.name)
+		require.Equal(t, "internal server error", resp.Message, tc.name)
+	}
+
+	// Errors which encode to something meaningful are written as is.
+	rw := httptest.NewRecorder()
+	httpapi.Write(context.Background(), rw, http.StatusBadRequest, &codersdk.Error{Response: codersdk.Response{Message: "Bad."}})
+	require.Equal(t, http.StatusBadRequest, rw.Code)
+	var resp codersdk.Response
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+	require.Equal(t, "Bad.", resp.Message)
+}
+
+func TestWriteContentLength(t *testing.T) {
+	t.Parallel()
+
+	rw := httptest.NewRecorder()
+	httpapi.Write(context.Background(), rw, http.StatusOK, codersdk.Response{Message: "Known length."})
+	require.Equal(t, strconv.Itoa(rw.Body.Len()), rw.Header().Get("Content-Length"))
+}
+
+func TestWriteRaw(t *testing.T) {
+	t.Parallel()
+
+	const value = "<a href=\"https://coder.com?a=1&b=2\">"
+
+	rw := httptest.NewRecorder()
+	httpapi.Write(context.Background(), rw, http.StatusOK, codersdk.Response{Message: value})
+	require.Contains(t, rw.Body.String(), `\u003ca href=\"https://coder.com?a=1\u0026b=2\"\u003e`)
+
+	rw = httptest.NewRecorder()
+	httpapi.WriteRaw(context.Background(), rw, http.StatusOK, codersdk.Response{Message: value})
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Contains(t, rw.Body.String(), `<a href=\"https://coder.com?a=1&b=2\">`)
+
+	var resp codersdk.Response
+	err := json.NewDecoder(rw.Body).Decode(&resp)
+	require.NoError(t, err)
+	require.Equal(t, value, resp.Message)
+}
+
+// sameFieldErrors fails validation twice on the same field, with details that
+// sort in the opposite order of their codes.
+type sameFieldErrors struct {
+	Name string `json:"name"`
+}
+
+func (sameFieldErrors) Validate() []codersdk.ValidationError {
+	return []codersdk.ValidationError{
+		{Field: "name", Detail: "a workspace already has this name", Code: "taken"},
+		{Field: "name", Detail: "is a reserved name", Code: "reserved"},
+	}
+}
+
 func TestRead(t *testing.T) {
 	t.Parallel()
 	t.Run("EmptyStruct", func(t *testing.T) {
@@ -103,6 +346,68 @@ func TestRead(t *testing.T) {
 		require.Equal(t, "hi", validate.Value)
 	})
 
+	t.Run("ValidateFailureSorted", func(t *testing.T) {
+		t.Parallel()
+		type toValidate struct {
+			Zeta  string `json:"zeta" validate:"required"`
+			Alpha string `json:"alpha" validate:"required"`
+			Mid   string `json:"mid" validate:"required"`
+		}
+
+		var bodies []string
+		for i := 0; i < 5; i++ {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString("{}"))
+			require.False(t, httpapi.Read(context.Background(), rw, r, &toValidate{}))
+			bodies = append(bodies, rw.Body.String())
+		}
+		for _, body := range bodies[1:] {
+			require.Equal(t, bodies[0], body)
+		}
+
+		var v codersdk.Response
+		err := json.Unmarshal([]byte(bodies[0]), &v)
+		require.NoError(t, err)
+		fields := make([]string, 0, len(v.Validations))
+		for _, validation := range v.Validations {
+			fields = append(fields, validation.Field)
+		}
+		require.Equal(t, []string{"alpha", "mid", "zeta"}, fields)
+	})
+
+	t.Run("ValidateFailureSortedByCode", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"name":"dev"}`))
+		require.False(t, httpapi.Read(context.Background(), rw, r, &sameFieldErrors{}))
+
+		var v codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&v))
+		codes := make([]string, 0, len(v.Validations))
+		for _, validation := range v.Validations {
+			codes = append(codes, validation.Code)
+		}
+		require.Equal(t, []string{"reserved", "taken"}, codes)
+	})
+
+	t.Run("BOM", func(t *testing.T) {
+		t.Parallel()
+		type toDecode struct {
+			Value string `json:"value"`
+		}
+		for _, body := range []string{
+			`{"value":"hi"}`,
+			"\ufeff" + `{"value":"hi"}`,
+			"\ufeff \n\t" + `{"value":"hi"}`,
+		} {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+			var v toDecode
+			require.True(t, httpapi.Read(context.Background(), rw, r, &v), "body %q", body)
+			require.Equal(t, "hi", v.Value)
+		}
+	})
+
 	t.Run("ValidateFailure", func(t *testing.T) {
 		t.Parallel()
 		type toValidate struct {
@@ -119,7 +424,231 @@ func TestRead(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, v.Validations, 1)
 		require.Equal(t, "value", v.Validations[0].Field)
-		require.Equal(t, "Validation failed for tag \"required\" with value: \"\"", v.Validations[0].Detail)
+		require.Equal(t, "value is a required field", v.Validations[0].Detail)
+	})
+}
+
+func TestReadCharset(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name string `json:"name"`
+	}
+	read := func(t *testing.T, contentType string, body []byte) (*httptest.ResponseRecorder, request, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		var v request
+		ok := httpapi.Read(context.Background(), rw, r, &v)
+		return rw, v, ok
+	}
+
+	t.Run("DefaultUTF8", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, "application/json", []byte(`{"name":"café"}`))
+		require.True(t, ok)
+		require.Equal(t, "café", v.Name)
+	})
+
+	t.Run("Latin1", func(t *testing.T) {
+		t.Parallel()
+		// "café" with é encoded as the single latin-1 byte 0xE9.
+		_, v, ok := read(t, "application/json; charset=ISO-8859-1", []byte("{\"name\":\"caf\xe9\"}"))
+		require.True(t, ok)
+		require.Equal(t, "café", v.Name)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, "application/json; charset=shift_jis", []byte(`{"name":"coder"}`))
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+}
+
+func TestReadTypeErrorPath(t *testing.T) {
+	t.Parallel()
+
+	type agent struct {
+		Name string `json:"name"`
+	}
+	type resource struct {
+		Agents []agent `json:"agents"`
+	}
+	type request struct {
+		Name      string     `json:"name"`
+		Resources []resource `json:"resources"`
+		Meta      struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+
+	for _, tc := range []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{name: "TopLevel", body: `{"name":1}`, field: "name"},
+		{name: "Nested", body: `{"name":"a","meta":{"count":"three"}}`, field: "meta.count"},
+		{name: "ArrayElement", body: `{"resources":[{"agents":[]},{"agents":[{"name":"a"},{"name":false}]}]}`, field: "resources[1].agents[1].name"},
+		{name: "ObjectForString", body: `{"resources":[{"agents":[{"name":{"first":"a"}}]}]}`, field: "resources[0].agents[0].name"},
+		{name: "BOM", body: "\xEF\xBB\xBF" + `{"meta":{"count":true}}`, field: "meta.count"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", bytes.NewBufferString(tc.body))
+			var v request
+			require.False(t, httpapi.Read(context.Background(), rw, r, &v))
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Len(t, resp.Validations, 1)
+			require.Equal(t, tc.field, resp.Validations[0].Field)
+			require.Equal(t, "invalid_type", resp.Validations[0].Code)
+		})
+	}
+}
+
+func TestReadReusesBuffers(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	// Bodies are read in sequence so pooled buffers are likely reused. Each
+	// must decode as if it were the first, regardless of what the previous
+	// request left unread.
+	for i, tc := range []struct {
+		body     string
+		expected request
+	}{
+		{body: "\xEF\xBB\xBF" + `{"name":"first","count":1}{"name":"leftover"}`, expected: request{Name: "first", Count: 1}},
+		{body: `{"count":2}`, expected: request{Count: 2}},
+		{body: `{"name":"` + strings.Repeat("a", 8192) + `"}`, expected: request{Name: strings.Repeat("a", 8192)}},
+		{body: `{}`, expected: request{}},
+	} {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(tc.body))
+		var v request
+		require.True(t, httpapi.Read(context.Background(), rw, r, &v), "request %d", i)
+		require.Equal(t, tc.expected, v, "request %d", i)
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	type request struct {
+		Name string   `json:"name" validate:"required"`
+		Tags []string `json:"tags"`
+	}
+	body := []byte(`{"name":"workspace","tags":["a","b","c"]}`)
+
+	// The request and recorder are reused so only Read's own allocations are
+	// reported.
+	reader := bytes.NewReader(body)
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Body = io.NopCloser(reader)
+	rw := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(body)
+		var v request
+		if !httpapi.Read(context.Background(), rw, r, &v) {
+			b.Fatal(rw.Body.String())
+		}
+	}
+}
+
+func TestValidateOnly(t *testing.T) {
+	t.Parallel()
+
+	type toValidate struct {
+		Value string `json:"value" validate:"required"`
+	}
+	newValue := func() any { return &toValidate{} }
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"value":"hi"}`))
+		require.True(t, httpapi.ValidateOnly(rw, r, newValue))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp codersdk.Response
+		err := json.NewDecoder(rw.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Equal(t, "valid", resp.Message)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{}`))
+		require.False(t, httpapi.ValidateOnly(rw, r, newValue))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		err := json.NewDecoder(rw.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "value", resp.Validations[0].Field)
+	})
+}
+
+type validatableRequest struct {
+	Start int `json:"start" validate:"required"`
+	End   int `json:"end"`
+}
+
+func (r validatableRequest) Validate() []codersdk.ValidationError {
+	if r.End < r.Start {
+		return []codersdk.ValidationError{{Field: "end", Detail: "must not be before start"}}
+	}
+	return nil
+}
+
+var _ httpapi.Validatable = validatableRequest{}
+
+func TestReadValidatable(t *testing.T) {
+	t.Parallel()
+
+	read := func(t *testing.T, body string) (bool, codersdk.Response) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+		ok := httpapi.Read(context.Background(), rw, r, &validatableRequest{})
+		var resp codersdk.Response
+		if !ok {
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		}
+		return ok, resp
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		ok, _ := read(t, `{"start":1,"end":2}`)
+		require.True(t, ok)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		ok, resp := read(t, `{"start":2,"end":1}`)
+		require.False(t, ok)
+		require.Equal(t, []codersdk.ValidationError{{Field: "end", Detail: "must not be before start"}}, resp.Validations)
+	})
+
+	t.Run("MergedWithTags", func(t *testing.T) {
+		t.Parallel()
+		ok, resp := read(t, `{"end":-1}`)
+		require.False(t, ok)
+		require.Len(t, resp.Validations, 2)
+		require.Equal(t, "end", resp.Validations[0].Field)
+		require.Equal(t, "start", resp.Validations[1].Field)
 	})
 }
 
@@ -155,3 +684,62 @@ func TestWebsocketCloseMsg(t *testing.T) {
 		assert.Equal(t, len(trunc), 123)
 	})
 }
+
+func TestServerSentEventSender(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Events", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		senderClosed := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			sendEvent, closed, err := httpapi.ServerSentEventSender(rw, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeData,
+				Data: map[string]string{"status": "<running>"},
+			}))
+			<-closed
+			close(senderClosed)
+		}))
+		defer srv.Close()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		require.NoError(t, err)
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		// Read the event before closing, since the stream doesn't end.
+		br := bufio.NewReader(res.Body)
+		var event strings.Builder
+		for !strings.HasSuffix(event.String(), "\n\n") {
+			line, err := br.ReadString('\n')
+			require.NoError(t, err)
+			event.WriteString(line)
+		}
+		require.Equal(t, "event: data\ndata: {\"status\":\"\\u003crunning\\u003e\"}\n\n", event.String())
+
+		// Disconnecting closes the sender.
+		_ = res.Body.Close()
+		select {
+		case <-senderClosed:
+		case <-ctx.Done():
+			t.Fatal("sender not closed after client disconnected")
+		}
+	})
+
+	t.Run("NotFlusher", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		_, _, err := httpapi.ServerSentEventSender(struct{ http.ResponseWriter }{rw}, httptest.NewRequest("GET", "/", nil))
+		require.Error(t, err)
+		require.Empty(t, rw.Header().Get("Content-Type"))
+		require.False(t, rw.Flushed)
+	})
+}
@@ -50,3 +50,56 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 	return xerrors.New("invalid duration")
 }
+
+// TimeLayout provides the layout a CustomTime is parsed with.
+type TimeLayout interface {
+	Layout() string
+}
+
+// DateTimeLayout parses timestamps like "2006-01-02 15:04:05" as UTC, as sent
+// by some legacy integrations.
+type DateTimeLayout struct{}
+
+// Layout implements TimeLayout.
+func (DateTimeLayout) Layout() string {
+	return time.DateTime
+}
+
+// CustomTime wraps time.Time to accept timestamps in the layout provided by L
+// when unmarshalling, in addition to RFC3339. The default time.Time only
+// accepts RFC3339, which not every client sends.
+//
+// This type always marshals as RFC3339, so responses stay consistent.
+type CustomTime[L TimeLayout] time.Time
+
+// Time returns the underlying time.Time.
+func (t CustomTime[L]) Time() time.Time {
+	return time.Time(t)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t CustomTime[L]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(t).Format(time.RFC3339))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *CustomTime[L]) UnmarshalJSON(b []byte) error {
+	var value string
+	err := json.Unmarshal(b, &value)
+	if err != nil {
+		return xerrors.Errorf("unmarshal JSON value: %w", err)
+	}
+
+	var layout L
+	parsed, err := time.Parse(layout.Layout(), value)
+	if err != nil {
+		var rfcErr error
+		parsed, rfcErr = time.Parse(time.RFC3339, value)
+		if rfcErr != nil {
+			return xerrors.Errorf("parse time %q with layout %q: %w", value, layout.Layout(), err)
+		}
+	}
+
+	*t = CustomTime[L](parsed)
+	return nil
+}
@@ -1,6 +1,7 @@
 package httpapi_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -166,3 +167,71 @@ func TestDuration(t *testing.T) {
 		}
 	})
 }
+
+func TestCustomTime(t *testing.T) {
+	t.Parallel()
+
+	type legacyRequest struct {
+		CreatedAt httpapi.CustomTime[httpapi.DateTimeLayout] `json:"created_at"`
+	}
+
+	t.Run("UnmarshalLayout", func(t *testing.T) {
+		t.Parallel()
+
+		var v legacyRequest
+		err := json.Unmarshal([]byte(`{"created_at":"2024-06-01 12:30:45"}`), &v)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 6, 1, 12, 30, 45, 0, time.UTC), v.CreatedAt.Time())
+	})
+
+	t.Run("UnmarshalRFC3339", func(t *testing.T) {
+		t.Parallel()
+
+		var v legacyRequest
+		err := json.Unmarshal([]byte(`{"created_at":"2024-06-01T12:30:45Z"}`), &v)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 6, 1, 12, 30, 45, 0, time.UTC), v.CreatedAt.Time())
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+
+		var v legacyRequest
+		err := json.Unmarshal([]byte(`{"created_at":"2024-06-01 12:30:45"}`), &v)
+		require.NoError(t, err)
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"created_at":"2024-06-01T12:30:45Z"}`, string(b))
+	})
+
+	t.Run("UnmarshalErrors", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			value       string
+			errContains string
+		}{
+			{
+				value:       "12345",
+				errContains: "unmarshal JSON value",
+			},
+			{
+				value:       `"06/01/2024"`,
+				errContains: "parse time",
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+
+			t.Run(c.value, func(t *testing.T) {
+				t.Parallel()
+
+				var ct httpapi.CustomTime[httpapi.DateTimeLayout]
+				err := ct.UnmarshalJSON([]byte(c.value))
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.errContains)
+			})
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// JSONAPIContentType is the media type mandated by the JSON:API spec.
+const JSONAPIContentType = "application/vnd.api+json"
+
+// JSONAPIResource is a JSON:API resource object.
+type JSONAPIResource struct {
+	Type       string      `json:"type"`
+	ID         string      `json:"id"`
+	Attributes interface{} `json:"attributes"`
+}
+
+// JSONAPIErrorSource points at the part of the request that caused an error.
+type JSONAPIErrorSource struct {
+	Pointer string `json:"pointer"`
+}
+
+// JSONAPIError is a JSON:API error object.
+type JSONAPIError struct {
+	Status string              `json:"status"`
+	Title  string              `json:"title"`
+	Detail string              `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource `json:"source,omitempty"`
+}
+
+// WriteJSONAPI writes a single resource as a JSON:API document. This only
+// exists for integrations that mandate the spec, all other handlers should use
+// Write.
+func WriteJSONAPI(ctx context.Context, rw http.ResponseWriter, status int, resourceType string, id string, attributes interface{}) {
+	_, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	writeJSONAPI(ctx, rw, status, struct {
+		Data JSONAPIResource `json:"data"`
+	}{Data: JSONAPIResource{Type: resourceType, ID: id, Attributes: attributes}})
+}
+
+// WriteJSONAPIErrors is the failure counterpart of WriteJSONAPI. Each
+// validation error in response becomes an error object whose source points at
+// the offending attribute. A response without validations becomes a single
+// error object.
+func WriteJSONAPIErrors(ctx context.Context, rw http.ResponseWriter, status int, response codersdk.Response) {
+	_, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	statusText := strconv.Itoa(status)
+	errs := make([]JSONAPIError, 0, len(response.Validations))
+	for _, v := range response.Validations {
+		errs = append(errs, JSONAPIError{
+			Status: statusText,
+			Title:  response.Message,
+			Detail: v.Detail,
+			Source: &JSONAPIErrorSource{Pointer: "/data/attributes/" + v.Field},
+		})
+	}
+	if len(errs) == 0 {
+		errs = append(errs, JSONAPIError{
+			Status: statusText,
+			Title:  response.Message,
+			Detail: response.Detail,
+		})
+	}
+	writeJSONAPI(ctx, rw, status, struct {
+		Errors []JSONAPIError `json:"errors"`
+	}{Errors: errs})
+}
+
+func writeJSONAPI(ctx context.Context, rw http.ResponseWriter, status int, document interface{}) {
+	writeJSONAs(ctx, rw, status, JSONAPIContentType, document, true, false)
+}
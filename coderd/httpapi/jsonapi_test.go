@@ -0,0 +1,64 @@
+package httpapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWriteJSONAPI(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Data", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteJSONAPI(context.Background(), rw, http.StatusOK, "workspaces", "abc", map[string]string{"name": "dev"})
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, httpapi.JSONAPIContentType, rw.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"data":{"type":"workspaces","id":"abc","attributes":{"name":"dev"}}}`, rw.Body.String())
+		require.Equal(t, strconv.Itoa(rw.Body.Len()), rw.Header().Get("Content-Length"))
+	})
+
+	t.Run("EncodeError", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteJSONAPI(context.Background(), rw, http.StatusOK, "workspaces", "abc", map[string]any{"name": make(chan int)})
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+
+	t.Run("Validations", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteJSONAPIErrors(context.Background(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Validation failed.",
+			Validations: []codersdk.ValidationError{
+				{Field: "name", Detail: "required"},
+				{Field: "ttl_ms", Detail: "must be positive"},
+			},
+		})
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Equal(t, httpapi.JSONAPIContentType, rw.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"errors":[
+			{"status":"400","title":"Validation failed.","detail":"required","source":{"pointer":"/data/attributes/name"}},
+			{"status":"400","title":"Validation failed.","detail":"must be positive","source":{"pointer":"/data/attributes/ttl_ms"}}
+		]}`, rw.Body.String())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteJSONAPIErrors(context.Background(), rw, http.StatusNotFound, codersdk.Response{
+			Message: "Not found.",
+			Detail:  "workspace abc",
+		})
+		require.Equal(t, http.StatusNotFound, rw.Code)
+		require.JSONEq(t, `{"errors":[{"status":"404","title":"Not found.","detail":"workspace abc"}]}`, rw.Body.String())
+	})
+}
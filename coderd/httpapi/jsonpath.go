@@ -0,0 +1,113 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// typeErrorValidation reports err against the JSON path of the offending
+// value, e.g. "resources[1].agents[0].name". data is the body decoded so far.
+// encoding/json only provides the path of object keys without array indexes,
+// so it's used as a fallback.
+func typeErrorValidation(err *json.UnmarshalTypeError, data []byte) codersdk.ValidationError {
+	field := err.Field
+	if err.Offset > 0 && err.Offset <= int64(len(data)) {
+		if path := jsonPathAt(data[:err.Offset]); path != "" {
+			field = path
+		}
+	}
+	return codersdk.ValidationError{
+		Field:  field,
+		Detail: fmt.Sprintf("must be of type %s, got %s", err.Type, err.Value),
+		Code:   string(ErrorCodeInvalidType),
+	}
+}
+
+// jsonPathAt returns the path of the last value in data, which is a prefix of
+// a JSON document.
+func jsonPathAt(data []byte) string {
+	type frame struct {
+		array bool
+		key   string
+		index int
+		// expectKey is true when the next string in an object is a key.
+		expectKey bool
+	}
+	var stack []*frame
+	// beginValue is called before every value to advance the parent array.
+	beginValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.array {
+			top.index++
+		}
+	}
+	// endValue is called after every value so the parent object expects a key.
+	endValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if !top.array {
+			top.expectKey = true
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{':
+				beginValue()
+				stack = append(stack, &frame{expectKey: true})
+			case '[':
+				beginValue()
+				stack = append(stack, &frame{array: true, index: -1})
+			case '}', ']':
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+				endValue()
+			}
+			continue
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; !top.array && top.expectKey {
+				top.key, _ = tok.(string)
+				top.expectKey = false
+				continue
+			}
+		}
+		beginValue()
+		endValue()
+	}
+
+	var path strings.Builder
+	for _, f := range stack {
+		if f.array {
+			if f.index >= 0 {
+				path.WriteString("[" + strconv.Itoa(f.index) + "]")
+			}
+			continue
+		}
+		if f.key == "" {
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(f.key)
+	}
+	return path.String()
+}
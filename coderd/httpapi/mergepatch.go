@@ -0,0 +1,140 @@
+package httpapi
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ReadMergePatch applies an RFC 7386 JSON merge patch from the request body
+// onto existing, which must be a pointer. Objects are merged recursively and
+// null removes a key, resetting the field to its zero value. The result is
+// validated like Read, and existing is only modified if it's valid.
+func ReadMergePatch(ctx context.Context, rw http.ResponseWriter, r *http.Request, existing interface{}) bool {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	ptr := reflect.ValueOf(existing)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error applying merge patch.",
+			Detail:  "existing value must be a non-nil pointer",
+		})
+		return false
+	}
+
+	var patch interface{}
+	err := json.NewDecoder(skipBOM(r.Body)).Decode(&patch)
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request body must be valid JSON.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	data, err := json.Marshal(existing)
+	if err != nil {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error applying merge patch.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	var target interface{}
+	err = json.Unmarshal(data, &target)
+	if err != nil {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error applying merge patch.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error applying merge patch.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	// Decode into a copy so fields JSON doesn't see, like unexported ones or
+	// those tagged "-", are kept. The fields it does see are reset first, so
+	// removed keys end up as zero values and maps aren't merged into.
+	patched := reflect.New(ptr.Elem().Type())
+	patched.Elem().Set(ptr.Elem())
+	resetJSONFields(patched.Elem())
+	err = json.Unmarshal(merged, patched.Interface())
+	if err != nil {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Merge patch produced an invalid value.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+	if !validateRequest(ctx, rw, r, patched.Interface()) {
+		return false
+	}
+	ptr.Elem().Set(patched.Elem())
+	return true
+}
+
+// resetJSONFields zeroes the fields of the struct v that are encoded as JSON.
+// Nested structs are reset field by field, so their hidden fields are kept,
+// unless they decode themselves. Pointers are zeroed rather than followed, so
+// the value they were copied from isn't modified.
+func resetJSONFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("json") == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && !decodesItself(value.Type()) {
+			resetJSONFields(value)
+			continue
+		}
+		if value.CanSet() {
+			value.SetZero()
+		}
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decodesItself returns whether values of t implement their own JSON or text
+// decoding, which replaces them as a whole.
+func decodesItself(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// mergePatch implements the MergePatch algorithm from RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
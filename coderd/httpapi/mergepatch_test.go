@@ -0,0 +1,113 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestReadMergePatch(t *testing.T) {
+	t.Parallel()
+
+	type settings struct {
+		Theme    string            `json:"theme"`
+		Labels   map[string]string `json:"labels"`
+		Schedule *struct {
+			Cron string `json:"cron"`
+			TTL  int    `json:"ttl"`
+		} `json:"schedule"`
+	}
+	type resource struct {
+		Name     string   `json:"name" validate:"required"`
+		Tags     []string `json:"tags"`
+		Settings settings `json:"settings"`
+		Secret   string   `json:"-"`
+		revision int
+	}
+	existing := func() resource {
+		v := resource{
+			Name:     "dev",
+			Tags:     []string{"a", "b"},
+			Secret:   "keep",
+			revision: 3,
+			Settings: settings{
+				Theme:  "dark",
+				Labels: map[string]string{"team": "core", "env": "prod"},
+			},
+		}
+		v.Settings.Schedule = &struct {
+			Cron string `json:"cron"`
+			TTL  int    `json:"ttl"`
+		}{Cron: "0 9 * * *", TTL: 60}
+		return v
+	}
+	patch := func(t *testing.T, v *resource, body string) bool {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("PATCH", "/", bytes.NewBufferString(body))
+		ok := httpapi.ReadMergePatch(context.Background(), rw, r, v)
+		if !ok {
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+		}
+		return ok
+	}
+
+	t.Run("NestedMerge", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		require.True(t, patch(t, &v, `{"settings":{"labels":{"env":"staging"},"schedule":{"ttl":120}}}`))
+		require.Equal(t, "dark", v.Settings.Theme)
+		require.Equal(t, map[string]string{"team": "core", "env": "staging"}, v.Settings.Labels)
+		require.Equal(t, "0 9 * * *", v.Settings.Schedule.Cron)
+		require.Equal(t, 120, v.Settings.Schedule.TTL)
+	})
+
+	t.Run("NullDeletes", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		require.True(t, patch(t, &v, `{"settings":{"labels":{"team":null},"schedule":null}}`))
+		require.Equal(t, map[string]string{"env": "prod"}, v.Settings.Labels)
+		require.Nil(t, v.Settings.Schedule)
+		require.Equal(t, "dark", v.Settings.Theme)
+	})
+
+	t.Run("ScalarReplace", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		require.True(t, patch(t, &v, `{"name":"prod","tags":["c"]}`))
+		require.Equal(t, "prod", v.Name)
+		// Arrays are replaced wholesale, not merged.
+		require.Equal(t, []string{"c"}, v.Tags)
+	})
+
+	t.Run("HiddenFieldsKept", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		require.True(t, patch(t, &v, `{"name":"b","settings":null}`))
+		require.Equal(t, "b", v.Name)
+		require.Equal(t, "keep", v.Secret)
+		require.Equal(t, 3, v.revision)
+		require.Equal(t, settings{}, v.Settings)
+	})
+
+	t.Run("ExistingMapUnchanged", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		labels := v.Settings.Labels
+		require.True(t, patch(t, &v, `{"settings":{"labels":{"team":null}}}`))
+		require.Equal(t, map[string]string{"env": "prod"}, v.Settings.Labels)
+		require.Equal(t, map[string]string{"team": "core", "env": "prod"}, labels)
+	})
+
+	t.Run("InvalidResultUnchanged", func(t *testing.T) {
+		t.Parallel()
+		v := existing()
+		require.False(t, patch(t, &v, `{"name":null}`))
+		require.Equal(t, existing(), v)
+	})
+}
@@ -0,0 +1,196 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/coder/coder/v2/coderd/httpapi/routepattern"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+type responseMetrics struct {
+	responses          *prometheus.CounterVec
+	responseSizes      *prometheus.HistogramVec
+	encodeLatencies    *prometheus.HistogramVec
+	validationFailures *prometheus.CounterVec
+}
+
+// metrics are recorded if Instrument was called.
+var metrics atomic.Pointer[responseMetrics]
+
+// Instrument records metrics of the responses written by this package in
+// registry: the number of responses by status class, their encoded sizes and
+// how long encoding took, and how often each field fails validation and why.
+// All are labeled by the pattern of the route, e.g. "/api/v2/users/{user}",
+// whether it's matched by chi or recorded with httpmw.SetRoutePattern, as
+// httpmw.ServeMuxRoutePattern does. Only the registry of the latest call is
+// recorded in, and calling it again with the same registry reuses its
+// collectors.
+//
+// The route is only known from the request context, so it's empty for
+// responses written without it, like those of ResourceNotFound,
+// InternalServerError, WriteRateLimited, WriteAccepted, WriteFieldErrors,
+// WriteListWithCount and WriteError. To count errors by route, write them
+// with Write or WriteErrorCtx and the request context.
+func Instrument(registry prometheus.Registerer) {
+	metrics.Store(&responseMetrics{
+		responses: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "responses_total",
+			Help:      "The total number of API responses written, by status class such as 4xx.",
+		}, []string{"route", "status_class"})),
+		responseSizes: registerCollector(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "response_size_bytes",
+			Help:      "Size distribution of encoded API response bodies in bytes.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10), // 64B to 16MiB
+		}, []string{"route"})),
+		encodeLatencies: registerCollector(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "response_encode_seconds",
+			Help:      "Latency distribution of encoding API response bodies in seconds.",
+			Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.010, 0.050, 0.100, 0.500, 1},
+		}, []string{"route"})),
+		validationFailures: registerCollector(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "api",
+			Name:      "validation_failures_total",
+			Help:      "The total number of request fields rejected, by the validation tag or error code rejecting them.",
+		}, []string{"route", "field", "code"})),
+	})
+}
+
+// registerCollector registers c in registry, or returns the equal collector
+// that's already registered. Like promauto, it panics on other errors, which
+// are programming mistakes.
+func registerCollector[C prometheus.Collector](registry prometheus.Registerer, c C) C {
+	err := registry.Register(c)
+	if err == nil {
+		return c
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
+
+// observeResponse records a response with an encoded body of size bytes.
+func observeResponse(ctx context.Context, status, size int, encodeLatency time.Duration) {
+	m := metrics.Load()
+	if m == nil {
+		return
+	}
+	route := routePattern(ctx)
+	m.responses.WithLabelValues(route, strconv.Itoa(status/100)+"xx").Inc()
+	m.responseSizes.WithLabelValues(route).Observe(float64(size))
+	m.encodeLatencies.WithLabelValues(route).Observe(encodeLatency.Seconds())
+}
+
+// observeValidationFailure records a field rejected by a validation tag or a
+// type's Validate method, whose names are declared by the server.
+func observeValidationFailure(ctx context.Context, field, code string) {
+	m := metrics.Load()
+	if m == nil {
+		return
+	}
+	m.validationFailures.WithLabelValues(routePattern(ctx), fieldIndexes.ReplaceAllString(field, "[]"), code).Inc()
+}
+
+// observeValidationFailures records each of validations as rejected with its
+// code, or with code if it has none.
+func observeValidationFailures(ctx context.Context, validations []codersdk.ValidationError, code ErrorCode) {
+	for _, v := range validations {
+		observeValidationFailure(ctx, v.Field, validationCode(v, code))
+	}
+}
+
+// observeDecodeFailures records each of validations as rejected with code
+// while decoding into a value of typ. Their fields are JSON paths taken from
+// the body, so only ones declared by typ are recorded as is.
+func observeDecodeFailures(ctx context.Context, typ reflect.Type, validations []codersdk.ValidationError, code ErrorCode) {
+	for _, v := range validations {
+		observeValidationFailure(ctx, declaredField(typ, v.Field), validationCode(v, code))
+	}
+}
+
+func validationCode(v codersdk.ValidationError, fallback ErrorCode) string {
+	if v.Code != "" {
+		return v.Code
+	}
+	return string(fallback)
+}
+
+// unknownField labels fields that typ doesn't declare, or that are map keys,
+// which are chosen by clients.
+const unknownField = "<unknown>"
+
+// fieldIndexes match the indexes of field paths like "resources[1].name", or
+// the map keys of ones like "labels[env]", which are dropped to keep the
+// cardinality of labels bounded.
+var fieldIndexes = regexp.MustCompile(`\[[^\]]*\]`)
+
+// declaredField returns the JSON path field with indexes dropped and map keys
+// replaced by "{}" if every part of it is declared by typ, and unknownField
+// otherwise.
+func declaredField(typ reflect.Type, field string) string {
+	if typ == nil || field == "" {
+		return unknownField
+	}
+	path := fieldIndexes.ReplaceAllString(field, "[]")
+	var parts []string
+	for _, part := range strings.Split(path, ".") {
+		name := strings.TrimRight(part, "[]")
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		switch {
+		case name == "":
+			// The body itself is an array.
+			parts = append(parts, "")
+		case typ.Kind() == reflect.Struct:
+			f, ok := jsonFields(typ)[normalizeKey(name)]
+			if !ok {
+				return unknownField
+			}
+			typ = f.typ
+			parts = append(parts, f.name)
+		case typ.Kind() == reflect.Map:
+			typ = typ.Elem()
+			parts = append(parts, "{}")
+		default:
+			return unknownField
+		}
+		for i := len(name); i < len(part); i += 2 {
+			for typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+				return unknownField
+			}
+			typ = typ.Elem()
+			parts[len(parts)-1] += "[]"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func routePattern(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	return routepattern.FromContext(ctx)
+}
@@ -0,0 +1,117 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpapi/routepattern"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestInstrument(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	httpapi.Instrument(registry)
+	// Instrumenting again reuses the registered collectors.
+	httpapi.Instrument(registry)
+	t.Parallel()
+
+	type resource struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type request struct {
+		Name      string            `json:"name" validate:"required,username"`
+		Resources []resource        `json:"resources" validate:"dive"`
+		Labels    map[string]string `json:"labels" validate:"dive,keys,username,endkeys"`
+		Counts    map[string]int    `json:"counts"`
+	}
+	r := chi.NewRouter()
+	r.Get("/instrumented/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.Response{Message: "Hi."})
+	})
+	r.Post("/instrumented/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		var req request
+		if !httpapi.Read(r.Context(), rw, r, &req) {
+			return
+		}
+		httpapi.Write(r.Context(), rw, http.StatusCreated, req)
+	})
+	r.Post("/instrumented/{id}/strict", func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = httpapi.Decode[request](rw, r)
+	})
+	// ServeMux routes are labeled by the pattern recorded for them, like
+	// httpmw.ServeMuxRoutePattern does.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /muxed/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		httpapi.WriteErrorCtx(r.Context(), rw, httpapi.NewAPIError(http.StatusNotFound, httpapi.ErrorCodeNotFound, "Not found."))
+	})
+	serveMux := func(path string) {
+		r := httptest.NewRequest("GET", path, nil)
+		r = r.WithContext(routepattern.WithHolder(r.Context()))
+		_, pattern := mux.Handler(r)
+		routepattern.Set(r.Context(), pattern)
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	serve := func(method, path, body string) {
+		rw := httptest.NewRecorder()
+		r.ServeHTTP(rw, httptest.NewRequest(method, path, strings.NewReader(body)))
+	}
+
+	serve("GET", "/instrumented/1", "")
+	serve("GET", "/instrumented/2", "")
+	serve("POST", "/instrumented/3", `{"name":"dev","resources":[{"name":"vm"}]}`)
+	serve("POST", "/instrumented/4", `{"name":"not valid","resources":[{"name":"vm"},{}]}`)
+	serve("POST", "/instrumented/5", `{"name":"dev","resources":[{"name":1}]}`)
+	serve("POST", "/instrumented/6/strict", `{"name":"dev","nmae":"dev"}`)
+	// Map keys are chosen by clients, so they aren't used as labels.
+	serve("POST", "/instrumented/7", `{"name":"dev","labels":{"not valid":"x"}}`)
+	serve("POST", "/instrumented/8", `{"name":"dev","counts":{"client-chosen":"x"}}`)
+	serveMux("/muxed/1")
+	serveMux("/muxed/2")
+
+	const route = "/instrumented/{id}"
+	// Metrics are keyed by their labels, so other tests writing responses
+	// concurrently don't affect the ones of this route.
+	gathered, err := registry.Gather()
+	require.NoError(t, err)
+	counts := map[string]float64{}
+	for _, family := range gathered {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch family.GetName() {
+			case "coderd_api_responses_total":
+				counts["responses "+labels["route"]+" "+labels["status_class"]] = metric.GetCounter().GetValue()
+			case "coderd_api_validation_failures_total":
+				counts["failures "+labels["route"]+" "+labels["field"]+" "+labels["code"]] = metric.GetCounter().GetValue()
+			case "coderd_api_response_size_bytes":
+				counts["sizes "+labels["route"]] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	require.Equal(t, 3.0, counts["responses "+route+" 2xx"])
+	require.Equal(t, 4.0, counts["responses "+route+" 4xx"])
+	require.Equal(t, 1.0, counts["responses "+route+"/strict 4xx"])
+	require.Equal(t, 2.0, counts["responses GET /muxed/{id} 4xx"])
+	require.Equal(t, 7.0, counts["sizes "+route])
+	require.Equal(t, 1.0, counts["failures "+route+" name username"])
+	require.Equal(t, 1.0, counts["failures "+route+" name required"])
+	// Indexes are dropped from paths to bound the label's cardinality.
+	require.Equal(t, 1.0, counts["failures "+route+" resources[].name invalid_type"])
+	require.Equal(t, 1.0, counts["failures "+route+"/strict <unknown> unknown_field"])
+	require.Equal(t, 1.0, counts["failures "+route+" labels[] username"])
+	require.Equal(t, 1.0, counts["failures "+route+" counts.{} invalid_type"])
+	for key := range counts {
+		require.NotContains(t, key, "client-chosen")
+		require.NotContains(t, key, "not valid")
+	}
+}
@@ -1,7 +1,11 @@
 package httpapi
 
 import (
+	"math"
+	"net/mail"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/moby/moby/pkg/namesgenerator"
@@ -13,9 +17,35 @@ var (
 	usernameReplace    = regexp.MustCompile("[^a-zA-Z0-9-]*")
 
 	templateVersionName = regexp.MustCompile(`^[a-zA-Z0-9]+(?:[_.-]{1}[a-zA-Z0-9]+)*$`)
+	dnsLabel            = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+	k8sLabelName        = regexp.MustCompile(`^[A-Za-z0-9](?:[-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	k8sDNSSubdomain     = regexp.MustCompile(`^[a-z0-9](?:[-a-z0-9]*[a-z0-9])?(?:\.[a-z0-9](?:[-a-z0-9]*[a-z0-9])?)*$`)
 	templateDisplayName = regexp.MustCompile(`^[^\s](.*[^\s])?$`)
+	quantityPattern     = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+))(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E|[eE][+-]?[0-9]+)?$`)
 )
 
+// quantitySuffixes are the multipliers of Kubernetes quantity suffixes. The
+// decimal exponent form, like "1e3", is handled separately.
+var quantitySuffixes = map[string]float64{
+	"":   1,
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
 // UsernameFrom returns a best-effort username from the provided string.
 //
 // It first attempts to validate the incoming string, which will
@@ -106,3 +136,203 @@ func NormalizeRealUsername(str string) string {
 	}
 	return s
 }
+
+// FQDNValid returns whether the input string is a syntactically valid fully
+// qualified domain name. A URL is also accepted, in which case its host is
+// checked. No DNS lookup is performed.
+func FQDNValid(str string) error {
+	host := str
+	if strings.Contains(str, "://") {
+		u, err := url.Parse(str)
+		if err != nil {
+			return xerrors.Errorf("invalid url: %w", err)
+		}
+		host = u.Hostname()
+	}
+	host = strings.TrimSuffix(host, ".")
+	if len(host) > 253 {
+		return xerrors.New("must be <= 253 characters")
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return xerrors.New("must be a fully qualified domain name")
+	}
+	for _, label := range labels {
+		if len(label) > 63 {
+			return xerrors.Errorf("label %q must be <= 63 characters", label)
+		}
+		if !dnsLabel.MatchString(label) {
+			return xerrors.Errorf("label %q must be alphanumeric with hyphens", label)
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return xerrors.New("top-level domain must not be numeric")
+	}
+	return nil
+}
+
+// BareDomainValid returns whether the input string is a fully qualified domain
+// name on its own, like "apps.example.com", without the scheme, port, path or
+// query of a URL.
+func BareDomainValid(str string) error {
+	switch {
+	case strings.Contains(str, "://"):
+		return xerrors.New("must not include a scheme such as https://")
+	case strings.ContainsAny(str, "/"):
+		return xerrors.New("must not include a path")
+	case strings.ContainsAny(str, "?#"):
+		return xerrors.New("must not include a query or fragment")
+	case strings.Contains(str, ":"):
+		return xerrors.New("must not include a port")
+	case strings.Contains(str, "@"):
+		return xerrors.New("must not include user info")
+	}
+	return FQDNValid(str)
+}
+
+// EmailValid returns whether the input string is a deliverable looking email
+// address. It's stricter than the built-in email tag: display names, quoted
+// local parts and IP literal domains are rejected, and the domain must be a
+// fully qualified domain name that could have an MX record. No DNS lookup is
+// performed.
+func EmailValid(str string) error {
+	addr, err := mail.ParseAddress(str)
+	if err != nil {
+		return xerrors.Errorf("invalid address: %w", err)
+	}
+	if addr.Name != "" || addr.Address != str {
+		return xerrors.New("must be a bare address without a display name")
+	}
+	at := strings.LastIndex(str, "@")
+	local, domain := str[:at], str[at+1:]
+	switch {
+	case len(str) > 254:
+		return xerrors.New("must be <= 254 characters")
+	case len(local) > 64:
+		return xerrors.New("local part must be <= 64 characters")
+	case strings.HasPrefix(local, `"`):
+		return xerrors.New("local part must not be quoted")
+	case strings.HasPrefix(domain, "["):
+		return xerrors.New("domain must not be an IP address")
+	}
+	err = FQDNValid(domain)
+	if err != nil {
+		return xerrors.Errorf("domain: %w", err)
+	}
+	return nil
+}
+
+// NormalizeEmail lowercases the domain of an email address, which is case
+// insensitive. The local part is left as is, since mail servers may treat it
+// as case sensitive.
+func NormalizeEmail(str string) string {
+	i := strings.LastIndex(str, "@")
+	if i < 0 {
+		return str
+	}
+	return str[:i+1] + strings.ToLower(str[i+1:])
+}
+
+// ParseQuantity parses a Kubernetes resource quantity, such as "500m", "2Gi"
+// or "1.5", and returns its value in base units, e.g. 0.5 CPUs or 2147483648
+// bytes. It follows the grammar of k8s.io/apimachinery's resource.Quantity,
+// but returns a float64 so precision is lost for very large or small values.
+func ParseQuantity(str string) (float64, error) {
+	match := quantityPattern.FindStringSubmatch(str)
+	if match == nil {
+		return 0, xerrors.New(`must be a quantity like "500m", "2Gi" or "1.5"`)
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, xerrors.Errorf("invalid number %q: %w", match[1], err)
+	}
+	suffix := match[2]
+	if multiplier, ok := quantitySuffixes[suffix]; ok {
+		return number * multiplier, nil
+	}
+	exponent, err := strconv.Atoi(suffix[1:])
+	if err != nil {
+		return 0, xerrors.Errorf("invalid exponent %q: %w", suffix, err)
+	}
+	return number * math.Pow10(exponent), nil
+}
+
+// GitRefValid returns whether the input string is a valid git branch or tag
+// name, following the rules of `git check-ref-format --allow-onelevel`.
+func GitRefValid(str string) error {
+	switch {
+	case str == "":
+		return xerrors.New("must not be empty")
+	case str == "@":
+		return xerrors.New(`must not be "@"`)
+	case strings.HasPrefix(str, "/") || strings.HasSuffix(str, "/"):
+		return xerrors.New(`must not start or end with "/"`)
+	case strings.HasSuffix(str, "."):
+		return xerrors.New(`must not end with "."`)
+	}
+	for _, sequence := range []string{"..", "//", "@{"} {
+		if strings.Contains(str, sequence) {
+			return xerrors.Errorf("must not contain %q", sequence)
+		}
+	}
+	for _, r := range str {
+		if r < 0x20 || r == 0x7f {
+			return xerrors.New("must not contain control characters")
+		}
+		if strings.ContainsRune(" ~^:?*[\\", r) {
+			return xerrors.Errorf("must not contain %q", r)
+		}
+	}
+	for _, component := range strings.Split(str, "/") {
+		if strings.HasPrefix(component, ".") {
+			return xerrors.Errorf(`component %q must not start with "."`, component)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return xerrors.Errorf(`component %q must not end with ".lock"`, component)
+		}
+	}
+	return nil
+}
+
+// K8sLabelValueValid returns whether the input string is a valid Kubernetes
+// label value: empty, or at most 63 alphanumeric characters, '-', '_' or '.'
+// that start and end with an alphanumeric character.
+func K8sLabelValueValid(str string) error {
+	if str == "" {
+		return nil
+	}
+	if len(str) > 63 {
+		return xerrors.New("must be <= 63 characters")
+	}
+	if !k8sLabelName.MatchString(str) {
+		return xerrors.New("must be alphanumeric with '-', '_' or '.', starting and ending with an alphanumeric character")
+	}
+	return nil
+}
+
+// K8sLabelKeyValid returns whether the input string is a valid Kubernetes
+// label or annotation key: a name with the same rules as a label value, but
+// required, optionally prefixed by a DNS subdomain and '/'.
+func K8sLabelKeyValid(str string) error {
+	prefix, name, found := strings.Cut(str, "/")
+	if !found {
+		name, prefix = prefix, ""
+	} else {
+		if prefix == "" {
+			return xerrors.New("prefix must not be empty")
+		}
+		if len(prefix) > 253 {
+			return xerrors.New("prefix must be <= 253 characters")
+		}
+		if !k8sDNSSubdomain.MatchString(prefix) {
+			return xerrors.New("prefix must be a lowercase DNS subdomain")
+		}
+	}
+	if name == "" {
+		return xerrors.New("name must not be empty")
+	}
+	if err := K8sLabelValueValid(name); err != nil {
+		return xerrors.Errorf("name %w", err)
+	}
+	return nil
+}
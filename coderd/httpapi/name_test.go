@@ -1,6 +1,7 @@
 package httpapi_test
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -254,3 +255,32 @@ func TestUserRealNameValid(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQuantity(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		quantity string
+		expected float64
+	}{
+		{quantity: "500m", expected: 0.5},
+		{quantity: "2Gi", expected: 2 << 30},
+		{quantity: "1.5", expected: 1.5},
+		{quantity: ".5k", expected: 500},
+		{quantity: "-1Ki", expected: -1024},
+		{quantity: "3E2", expected: 300},
+		{quantity: "3E", expected: 3e18},
+	} {
+		t.Run(tc.quantity, func(t *testing.T) {
+			t.Parallel()
+			value, err := httpapi.ParseQuantity(tc.quantity)
+			require.NoError(t, err)
+			require.InDelta(t, tc.expected, value, 1e-9*math.Abs(tc.expected))
+		})
+	}
+
+	for _, quantity := range []string{"", "garbage", "1.2.3", "Gi", "1 Gi", "1gi"} {
+		_, err := httpapi.ParseQuantity(quantity)
+		require.Error(t, err, quantity)
+	}
+}
@@ -0,0 +1,61 @@
+package httpapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// MaxStreamLineBytes is the longest line ReadStream accepts.
+const MaxStreamLineBytes = 1 << 20
+
+// ReadStream decodes a newline-delimited JSON request body one line at a time,
+// without buffering the whole body. Each line is decoded into a fresh value
+// from newValue, validated and passed to onValue. Blank lines are skipped.
+//
+// Reading stops at the first decode, validation or callback error, which is
+// returned with the offending line number. A line longer than
+// MaxStreamLineBytes is an APIError that WriteError writes as a 413.
+func ReadStream(r *http.Request, newValue func() any, onValue func(any) error) error {
+	scanner := bufio.NewScanner(skipBOM(r.Body))
+	scanner.Buffer(nil, MaxStreamLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		value := newValue()
+		err := json.Unmarshal(data, value)
+		if err != nil {
+			return xerrors.Errorf("decode line %d: %w", line, err)
+		}
+		err = Validate.Struct(value)
+		if err != nil {
+			return xerrors.Errorf("validate line %d: %w", line, err)
+		}
+		err = onValue(value)
+		if err != nil {
+			return xerrors.Errorf("handle line %d: %w", line, err)
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		return nil
+	}
+	// The line that failed to be read wasn't counted.
+	line++
+	if errors.Is(err, bufio.ErrTooLong) {
+		apiErr := NewAPIError(http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge, fmt.Sprintf("Line %d of the request body is too large.", line))
+		apiErr.Detail = fmt.Sprintf("Lines must be at most %d bytes.", MaxStreamLineBytes)
+		return xerrors.Errorf("read line %d: %w", line, apiErr)
+	}
+	return xerrors.Errorf("read line %d: %w", line, err)
+}
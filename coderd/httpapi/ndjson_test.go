@@ -0,0 +1,85 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestReadStream(t *testing.T) {
+	t.Parallel()
+
+	type metric struct {
+		Name  string  `json:"name" validate:"required"`
+		Value float64 `json:"value"`
+	}
+	newMetric := func() any { return &metric{} }
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		body := "{\"name\":\"cpu\",\"value\":1}\n\n{\"name\":\"mem\",\"value\":2}\n{\"name\":\"disk\",\"value\":3}"
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+
+		var got []metric
+		err := httpapi.ReadStream(r, newMetric, func(v any) error {
+			got = append(got, *v.(*metric))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []metric{{"cpu", 1}, {"mem", 2}, {"disk", 3}}, got)
+	})
+
+	t.Run("InvalidLine", func(t *testing.T) {
+		t.Parallel()
+		body := "{\"name\":\"cpu\",\"value\":1}\n{\"value\":2}\n{\"name\":\"disk\",\"value\":3}\n"
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+
+		var got []metric
+		err := httpapi.ReadStream(r, newMetric, func(v any) error {
+			got = append(got, *v.(*metric))
+			return nil
+		})
+		require.ErrorContains(t, err, "line 2")
+		var validationErrors validator.ValidationErrors
+		require.ErrorAs(t, err, &validationErrors)
+		require.Equal(t, []metric{{"cpu", 1}}, got)
+	})
+
+	t.Run("MalformedLine", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString("{\"name\":\"cpu\"}\nnot json\n"))
+
+		err := httpapi.ReadStream(r, newMetric, func(any) error { return nil })
+		require.ErrorContains(t, err, "decode line 2")
+	})
+
+	t.Run("LineTooLong", func(t *testing.T) {
+		t.Parallel()
+		long := `{"name":"` + strings.Repeat("a", httpapi.MaxStreamLineBytes) + `"}`
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString("{\"name\":\"cpu\"}\n"+long+"\n{\"name\":\"mem\"}\n"))
+
+		var got []metric
+		err := httpapi.ReadStream(r, newMetric, func(v any) error {
+			got = append(got, *v.(*metric))
+			return nil
+		})
+		require.ErrorContains(t, err, "line 2")
+		require.Equal(t, []metric{{Name: "cpu"}}, got)
+
+		rw := httptest.NewRecorder()
+		httpapi.WriteError(rw, err)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		var resp httpapi.ErrorResponse
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, httpapi.ErrorCodePayloadTooLarge, resp.Code)
+		require.Contains(t, resp.Message, "Line 2")
+	})
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// ObjectStreamWriter streams a JSON object keyed by string, such as resources
+// keyed by ID, with a 200 without buffering the whole response. Each entry is
+// flushed as it's encoded. Call Open, then Encode for each entry, then Close.
+type ObjectStreamWriter struct {
+	rw      http.ResponseWriter
+	entries int
+	opened  bool
+	closed  bool
+}
+
+// NewObjectStreamWriter returns an ObjectStreamWriter which writes to rw.
+func NewObjectStreamWriter(rw http.ResponseWriter) *ObjectStreamWriter {
+	return &ObjectStreamWriter{rw: rw}
+}
+
+// Open writes the response headers and the start of the object.
+func (w *ObjectStreamWriter) Open() error {
+	if w.opened {
+		return xerrors.New("object stream already opened")
+	}
+	w.opened = true
+	w.rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.rw.WriteHeader(http.StatusOK)
+	return w.write([]byte("{"))
+}
+
+// Encode writes v under key. v is encoded before anything is written, so an
+// error encoding it leaves the stream valid. Keys aren't checked for
+// duplicates.
+func (w *ObjectStreamWriter) Encode(key string, v any) error {
+	if !w.opened || w.closed {
+		return xerrors.New("object stream is not open")
+	}
+	encodedKey, err := json.Marshal(key)
+	if err != nil {
+		return xerrors.Errorf("encode key: %w", err)
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return xerrors.Errorf("encode value for key %q: %w", key, err)
+	}
+
+	entry := make([]byte, 0, len(encodedKey)+len(value)+2)
+	if w.entries > 0 {
+		entry = append(entry, ',')
+	}
+	entry = append(entry, encodedKey...)
+	entry = append(entry, ':')
+	entry = append(entry, value...)
+	w.entries++
+	return w.write(entry)
+}
+
+// Close writes the end of the object. An object without entries is written
+// as {}.
+func (w *ObjectStreamWriter) Close() error {
+	if !w.opened || w.closed {
+		return xerrors.New("object stream is not open")
+	}
+	w.closed = true
+	return w.write([]byte("}\n"))
+}
+
+func (w *ObjectStreamWriter) write(b []byte) error {
+	_, err := w.rw.Write(b)
+	if err != nil {
+		return xerrors.Errorf("write: %w", err)
+	}
+	if f, ok := w.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestObjectStreamWriter(t *testing.T) {
+	t.Parallel()
+
+	type agent struct {
+		Name string `json:"name"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		entries map[string]agent
+		keys    []string
+	}{
+		{name: "Empty"},
+		{name: "One", entries: map[string]agent{"a1": {Name: "main"}}, keys: []string{"a1"}},
+		{
+			name: "Multiple",
+			entries: map[string]agent{
+				"a1":           {Name: "main"},
+				"a2":           {Name: "sidecar"},
+				`quoted "key"`: {Name: "<escaped>"},
+				"line\nbreak":  {Name: "newline"},
+			},
+			keys: []string{"a1", "a2", `quoted "key"`, "line\nbreak"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			w := httpapi.NewObjectStreamWriter(rw)
+			require.NoError(t, w.Open())
+			for _, key := range tc.keys {
+				require.NoError(t, w.Encode(key, tc.entries[key]))
+			}
+			require.NoError(t, w.Close())
+
+			require.Equal(t, http.StatusOK, rw.Code)
+			require.True(t, rw.Flushed)
+			require.True(t, json.Valid(rw.Body.Bytes()), rw.Body.String())
+			var got map[string]agent
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &got))
+			if tc.entries == nil {
+				require.Equal(t, "{}\n", rw.Body.String())
+				require.Empty(t, got)
+				return
+			}
+			require.Equal(t, tc.entries, got)
+		})
+	}
+
+	t.Run("EncodeError", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		w := httpapi.NewObjectStreamWriter(rw)
+		require.NoError(t, w.Open())
+		require.NoError(t, w.Encode("a1", agent{Name: "main"}))
+		require.Error(t, w.Encode("a2", make(chan int)))
+		require.NoError(t, w.Close())
+		require.JSONEq(t, `{"a1":{"name":"main"}}`, rw.Body.String())
+	})
+
+	t.Run("NotOpen", func(t *testing.T) {
+		t.Parallel()
+		w := httpapi.NewObjectStreamWriter(httptest.NewRecorder())
+		require.Error(t, w.Encode("a1", agent{}))
+		require.Error(t, w.Close())
+	})
+}
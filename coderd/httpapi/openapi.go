@@ -0,0 +1,414 @@
+package httpapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is an OpenAPI 3.0 schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// OpenAPIDocument is an OpenAPI 3.0 document holding only component schemas.
+type OpenAPIDocument struct {
+	OpenAPI    string            `json:"openapi"`
+	Info       OpenAPIInfo       `json:"info"`
+	Paths      map[string]any    `json:"paths"`
+	Components OpenAPIComponents `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+var (
+	schemaMu sync.Mutex
+	// schemaTypes are the types registered with RegisterSchema.
+	schemaTypes []reflect.Type
+)
+
+// RegisterSchema adds the types of values to the component schemas generated
+// by GenerateOpenAPI. The structs they reference are included too.
+func RegisterSchema(values ...any) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	for _, v := range values {
+		schemaTypes = append(schemaTypes, reflect.TypeOf(v))
+	}
+}
+
+// RegisterSchemaTag describes the constraint a validation tag enforces in
+// generated schemas, for tags registered outside httpapi. apply is called
+// with the schema of each field using tag and the tag param.
+func RegisterSchemaTag(tag string, apply func(s *Schema, param string)) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	schemaTags[tag] = apply
+}
+
+// GenerateOpenAPI returns a document with a component schema for each type
+// registered with RegisterSchema. The schemas describe what Read enforces:
+// fields are named by their json tags, fields tagged `required` are required,
+// and the constraints of validation tags such as `max` or `username` are
+// included. Schemas of structs are named like "codersdk.CreateUserRequest".
+func GenerateOpenAPI(title, version string) *OpenAPIDocument {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+
+	g := schemaGenerator{schemas: map[string]*Schema{}}
+	for _, typ := range schemaTypes {
+		g.schema(typ)
+	}
+	return &OpenAPIDocument{
+		OpenAPI:    "3.0.3",
+		Info:       OpenAPIInfo{Title: title, Version: version},
+		Paths:      map[string]any{},
+		Components: OpenAPIComponents{Schemas: g.schemas},
+	}
+}
+
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	uuidType            = reflect.TypeOf(uuid.UUID{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	optionalFieldType   = reflect.TypeOf((*optionalField)(nil)).Elem()
+	schemaNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// schema returns the schema of typ, adding the schemas of structs to the
+// components and referring to them.
+func (g *schemaGenerator) schema(typ reflect.Type) *Schema {
+	nullable := false
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		nullable = true
+	}
+	s := g.typeSchema(typ)
+	if nullable {
+		if s.Ref != "" {
+			// Siblings of $ref are ignored in OpenAPI 3.0.
+			return s
+		}
+		s.Nullable = true
+	}
+	return s
+}
+
+func (g *schemaGenerator) typeSchema(typ reflect.Type) *Schema {
+	switch typ {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if typ.Implements(optionalFieldType) && typ.Kind() == reflect.Struct {
+		// Optional[T] holds its T in its first field.
+		s := g.schema(typ.Field(0).Type)
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+	if typ.Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(jsonMarshalerType) {
+		// The encoding is custom, so nothing is known about its shape.
+		return &Schema{}
+	}
+	if typ.Implements(textMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(typ.Elem())}
+	case reflect.Struct:
+		name := schemaName(typ)
+		if _, ok := g.schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			g.schemas[name] = s
+			g.addFields(s, typ)
+			sort.Strings(s.Required)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// Interfaces can hold anything.
+		return &Schema{}
+	}
+}
+
+// addFields adds the properties of the fields of typ to s, flattening
+// embedded structs like encoding/json.
+func (g *schemaGenerator) addFields(s *Schema, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.SplitN(tag, ",", 2)[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		// Constraints are skipped for references, since siblings of $ref
+		// are ignored.
+		prop := g.schema(field.Type)
+		if format := field.Tag.Get("format"); format != "" && prop.Ref == "" {
+			prop.Format = format
+		}
+		if enums := field.Tag.Get("enums"); enums != "" && prop.Ref == "" {
+			for _, e := range strings.Split(enums, ",") {
+				prop.Enum = append(prop.Enum, enumValue(prop, e))
+			}
+		}
+		if required := applyValidateTag(prop, field.Tag.Get("validate")); required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyValidateTag adds the constraints of the validate tag to s, returning
+// whether the field is required.
+func applyValidateTag(s *Schema, tag string) bool {
+	required := false
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch {
+		case name == "required" && target == s:
+			// Rules after dive apply to the elements, which can't be
+			// required.
+			required = true
+		case target.Ref != "":
+		case name == "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		default:
+			if apply, ok := schemaTags[name]; ok {
+				apply(target, param)
+			}
+		}
+	}
+	return required
+}
+
+// schemaTags describe the constraints of validation tags in schemas.
+var schemaTags = map[string]func(s *Schema, param string){
+	"min":     sizeConstraint(false, false),
+	"gte":     sizeConstraint(false, false),
+	"gt":      sizeConstraint(false, true),
+	"max":     sizeConstraint(true, false),
+	"lte":     sizeConstraint(true, false),
+	"lt":      sizeConstraint(true, true),
+	"runemin": sizeConstraint(false, false),
+	"runemax": sizeConstraint(true, false),
+	"len": func(s *Schema, param string) {
+		sizeConstraint(false, false)(s, param)
+		sizeConstraint(true, false)(s, param)
+	},
+	"email":    format("email"),
+	"url":      format("uri"),
+	"http_url": format("uri"),
+	"uri":      format("uri"),
+	"uuid":     format("uuid"),
+	"oneof": func(s *Schema, param string) {
+		s.Enum = nil
+		for _, v := range strings.Fields(param) {
+			s.Enum = append(s.Enum, enumValue(s, v))
+		}
+	},
+	"username":                  namePattern(UsernameValidRegex, 32),
+	"organization_name":         namePattern(UsernameValidRegex, 32),
+	"template_name":             namePattern(UsernameValidRegex, 32),
+	"group_name":                namePattern(UsernameValidRegex, 32),
+	"workspace_name":            namePattern(UsernameValidRegex, 32),
+	"oauth2_app_name":           namePattern(UsernameValidRegex, 32),
+	"template_version_name":     namePattern(templateVersionName, 64),
+	"organization_display_name": displayNamePattern,
+	"template_display_name":     displayNamePattern,
+	"group_display_name":        displayNamePattern,
+	"user_real_name": func(s *Schema, _ string) {
+		s.MaxLength = intPtr(128)
+	},
+}
+
+// sizeConstraint returns the constraint of tags like min and max, which limit
+// the length of strings, the number of items of arrays and the value of
+// numbers.
+func sizeConstraint(upper, exclusive bool) func(s *Schema, param string) {
+	return func(s *Schema, param string) {
+		switch s.Type {
+		case "string", "array", "object":
+			if s.Format == "date-time" {
+				return
+			}
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				return
+			}
+			if exclusive {
+				if upper {
+					n--
+				} else {
+					n++
+				}
+			}
+			switch {
+			case s.Type == "string" && upper:
+				s.MaxLength = intPtr(n)
+			case s.Type == "string":
+				s.MinLength = intPtr(n)
+			case s.Type == "array" && upper:
+				s.MaxItems = intPtr(n)
+			case s.Type == "array":
+				s.MinItems = intPtr(n)
+			}
+		case "integer", "number":
+			f, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return
+			}
+			if upper {
+				s.Maximum = &f
+				s.ExclusiveMaximum = exclusive
+			} else {
+				s.Minimum = &f
+				s.ExclusiveMinimum = exclusive
+			}
+		}
+	}
+}
+
+func format(f string) func(s *Schema, _ string) {
+	return func(s *Schema, _ string) {
+		s.Format = f
+	}
+}
+
+func namePattern(re *regexp.Regexp, maxLength int) func(s *Schema, _ string) {
+	return func(s *Schema, _ string) {
+		s.Pattern = re.String()
+		s.MinLength = intPtr(1)
+		s.MaxLength = intPtr(maxLength)
+	}
+}
+
+// displayNamePattern allows empty display names, which DisplayNameValid
+// accepts.
+func displayNamePattern(s *Schema, _ string) {
+	s.Pattern = `^$|` + templateDisplayName.String()
+	s.MaxLength = intPtr(64)
+}
+
+// enumValue converts v to the type of s, so enums of numbers are numbers.
+func enumValue(s *Schema, v string) any {
+	switch s.Type {
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// schemaName names the schema of a struct by its package and type name, like
+// swag does.
+func schemaName(typ reflect.Type) string {
+	pkg := typ.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name := typ.Name()
+	if name == "" {
+		name = "anonymous"
+	}
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	return strings.Trim(schemaNameSanitizer.ReplaceAllString(name, "_"), "_")
+}
+
+func intPtr(i int) *int {
+	return &i
+}
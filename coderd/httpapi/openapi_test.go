@@ -0,0 +1,145 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+type openAPIMeta struct {
+	Labels map[string]string `json:"labels"`
+}
+
+type openAPINode struct {
+	Name     string         `json:"name"`
+	Children []*openAPINode `json:"children"`
+}
+
+type openAPIRequest struct {
+	openAPIMeta
+	Username    string                  `json:"username" validate:"required,username"`
+	DisplayName string                  `json:"display_name" validate:"template_display_name"`
+	Email       string                  `json:"email" validate:"required,email"`
+	Role        string                  `json:"role" validate:"omitempty,oneof=admin member"`
+	Priority    int                     `json:"priority" validate:"gte=1,lt=10"`
+	Tags        []string                `json:"tags" validate:"max=5,dive,min=2"`
+	OwnerID     uuid.UUID               `json:"owner_id" format:"uuid"`
+	Deadline    *time.Time              `json:"deadline,omitempty"`
+	TTL         httpapi.Optional[int64] `json:"ttl"`
+	Root        openAPINode             `json:"root" validate:"required"`
+	Status      string                  `json:"status" enums:"running,stopped"`
+	Ignored     string                  `json:"-"`
+	Custom      string                  `json:"custom" validate:"openapi_shout"`
+	unexported  string                  //nolint:unused
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	httpapi.RegisterSchemaTag("openapi_shout", func(s *httpapi.Schema, _ string) {
+		s.Pattern = "^[A-Z]+$"
+	})
+	httpapi.RegisterSchema(openAPIRequest{})
+	t.Parallel()
+
+	doc := httpapi.GenerateOpenAPI("Test API", "1.0.0")
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Equal(t, "Test API", doc.Info.Title)
+
+	// Round trip through JSON to assert on what clients see.
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var out struct {
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &out))
+
+	schema := doc.Components.Schemas["httpapi_test.openAPIRequest"]
+	require.NotNil(t, schema)
+	require.Equal(t, "object", schema.Type)
+	require.Equal(t, []string{"email", "root", "username"}, schema.Required)
+	props := schema.Properties
+
+	t.Run("Embedded", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "object", props["labels"].Type)
+		require.Equal(t, "string", props["labels"].AdditionalProperties.Type)
+		require.NotContains(t, doc.Components.Schemas, "httpapi_test.openAPIMeta")
+	})
+
+	t.Run("Skipped", func(t *testing.T) {
+		t.Parallel()
+		require.NotContains(t, props, "Ignored")
+		require.NotContains(t, props, "-")
+		require.NotContains(t, props, "unexported")
+	})
+
+	t.Run("Names", func(t *testing.T) {
+		t.Parallel()
+		username := props["username"]
+		require.Equal(t, httpapi.UsernameValidRegex.String(), username.Pattern)
+		require.Equal(t, 1, *username.MinLength)
+		require.Equal(t, 32, *username.MaxLength)
+		require.Equal(t, 64, *props["display_name"].MaxLength)
+		require.Regexp(t, "^\\^\\$\\|", props["display_name"].Pattern)
+	})
+
+	t.Run("Formats", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "email", props["email"].Format)
+		require.Equal(t, "uuid", props["owner_id"].Format)
+		require.Equal(t, "date-time", props["deadline"].Format)
+		require.True(t, props["deadline"].Nullable)
+	})
+
+	t.Run("Enums", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, []any{"admin", "member"}, props["role"].Enum)
+		require.Equal(t, []any{"running", "stopped"}, props["status"].Enum)
+	})
+
+	t.Run("Numbers", func(t *testing.T) {
+		t.Parallel()
+		priority := props["priority"]
+		require.Equal(t, "integer", priority.Type)
+		require.Equal(t, 1.0, *priority.Minimum)
+		require.False(t, priority.ExclusiveMinimum)
+		require.Equal(t, 10.0, *priority.Maximum)
+		require.True(t, priority.ExclusiveMaximum)
+	})
+
+	t.Run("Dive", func(t *testing.T) {
+		t.Parallel()
+		tags := props["tags"]
+		require.Equal(t, 5, *tags.MaxItems)
+		require.Nil(t, tags.MaxLength)
+		require.Equal(t, 2, *tags.Items.MinLength)
+	})
+
+	t.Run("Optional", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "integer", props["ttl"].Type)
+		require.Equal(t, "int64", props["ttl"].Format)
+		require.True(t, props["ttl"].Nullable)
+	})
+
+	t.Run("Reference", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "#/components/schemas/httpapi_test.openAPINode", props["root"].Ref)
+		node := out.Components.Schemas["httpapi_test.openAPINode"]
+		require.NotNil(t, node)
+		// Recursive types refer to themselves.
+		children := node["properties"].(map[string]any)["children"].(map[string]any)
+		require.Equal(t, "#/components/schemas/httpapi_test.openAPINode", children["items"].(map[string]any)["$ref"])
+	})
+
+	t.Run("CustomTag", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "^[A-Z]+$", props["custom"].Pattern)
+	})
+}
@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Optional is a tri-state JSON field for PATCH style requests, which tells an
+// omitted field (leave unchanged) apart from an explicit null (clear it) and
+// a value. The zero value is absent.
+//
+// Validation tags apply to the wrapped value. Absent and null fields are
+// skipped by `omitempty` and rejected by `required`, which also rejects a
+// present zero value. Use `requiredvalue` to only require presence. Optionals
+// of types other than the common ones registered here must be registered with
+// RegisterOptional to be validated.
+type Optional[T any] struct {
+	value   T
+	present bool
+	null    bool
+}
+
+var (
+	_ json.Marshaler   = Optional[string]{}
+	_ json.Unmarshaler = (*Optional[string])(nil)
+)
+
+// OptionalValue returns an Optional holding v.
+func OptionalValue[T any](v T) Optional[T] {
+	return Optional[T]{value: v, present: true}
+}
+
+// OptionalNull returns an Optional that was explicitly null.
+func OptionalNull[T any]() Optional[T] {
+	return Optional[T]{present: true, null: true}
+}
+
+// Set returns true if the field was provided with a non-null value.
+func (o Optional[T]) Set() bool {
+	return o.present && !o.null
+}
+
+// Null returns true if the field was provided as an explicit null.
+func (o Optional[T]) Null() bool {
+	return o.present && o.null
+}
+
+// Absent returns true if the field was omitted.
+func (o Optional[T]) Absent() bool {
+	return !o.present
+}
+
+// Value returns the wrapped value, which is the zero value unless Set.
+func (o Optional[T]) Value() T {
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler. Absent and null both marshal as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It's only called when the field
+// is present in the object.
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*o = OptionalNull[T]()
+		return nil
+	}
+	*o = Optional[T]{present: true}
+	// Type errors are returned as is so Read can report them per field.
+	return json.Unmarshal(b, &o.value)
+}
+
+// validationValue returns the wrapped value, or nil so the validator treats
+// absent and null fields as empty.
+func (o Optional[T]) validationValue() interface{} {
+	if !o.Set() {
+		return nil
+	}
+	return o.value
+}
+
+type optionalField interface {
+	validationValue() interface{}
+}
+
+func optionalValidationValue(v reflect.Value) interface{} {
+	return v.Interface().(optionalField).validationValue()
+}
+
+// RegisterOptional makes validation tags on Optional[T] fields apply to the
+// wrapped value. Like all registrations, it isn't safe to call concurrently
+// with validation and should happen during init.
+func RegisterOptional[T any]() {
+	registrationMu.Lock()
+	defer registrationMu.Unlock()
+
+	Validate.RegisterCustomTypeFunc(optionalValidationValue, Optional[T]{})
+}
+
+func registerOptionalTypes() {
+	Validate.RegisterCustomTypeFunc(optionalValidationValue,
+		Optional[string]{},
+		Optional[bool]{},
+		Optional[int]{},
+		Optional[int32]{},
+		Optional[int64]{},
+		Optional[float64]{},
+		Optional[time.Time]{},
+		Optional[uuid.UUID]{},
+		Optional[[]string]{},
+	)
+}
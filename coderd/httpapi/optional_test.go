@@ -0,0 +1,139 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	type patchRequest struct {
+		Name        httpapi.Optional[string] `json:"name" validate:"omitempty,max=8"`
+		Description httpapi.Optional[string] `json:"description"`
+		TTL         httpapi.Optional[int64]  `json:"ttl"`
+	}
+	read := func(t *testing.T, body string) (*httptest.ResponseRecorder, patchRequest, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("PATCH", "/", strings.NewReader(body))
+		var v patchRequest
+		ok := httpapi.Read(r.Context(), rw, r, &v)
+		return rw, v, ok
+	}
+
+	t.Run("States", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, `{"name":"dev","description":null}`)
+		require.True(t, ok)
+
+		require.True(t, v.Name.Set())
+		require.Equal(t, "dev", v.Name.Value())
+
+		require.True(t, v.Description.Null())
+		require.False(t, v.Description.Set())
+
+		require.True(t, v.TTL.Absent())
+		require.False(t, v.TTL.Null())
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"name":"much-too-long"}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "name", resp.Validations[0].Field)
+	})
+
+	t.Run("NullSkipsValidation", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, `{"name":null}`)
+		require.True(t, ok)
+		require.True(t, v.Name.Null())
+	})
+
+	t.Run("Required", func(t *testing.T) {
+		t.Parallel()
+		type request struct {
+			Name httpapi.Optional[string] `json:"name" validate:"required"`
+		}
+		for _, body := range []string{`{}`, `{"name":null}`} {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("PATCH", "/", strings.NewReader(body))
+			require.False(t, httpapi.Read(r.Context(), rw, r, &request{}), body)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+		}
+	})
+
+	t.Run("RequiredValue", func(t *testing.T) {
+		t.Parallel()
+		type request struct {
+			Count   httpapi.Optional[int] `json:"count" validate:"requiredvalue"`
+			Enabled *bool                 `json:"enabled" validate:"requiredvalue"`
+		}
+		for _, tc := range []struct {
+			name    string
+			body    string
+			invalid []string
+		}{
+			{name: "PresentZero", body: `{"count":0,"enabled":false}`},
+			{name: "Absent", body: `{}`, invalid: []string{"count", "enabled"}},
+			{name: "Null", body: `{"count":null,"enabled":null}`, invalid: []string{"count", "enabled"}},
+		} {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("PATCH", "/", strings.NewReader(tc.body))
+			ok := httpapi.Read(r.Context(), rw, r, &request{})
+			if len(tc.invalid) == 0 {
+				require.True(t, ok, tc.name)
+				continue
+			}
+			require.False(t, ok, tc.name)
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			fields := make([]string, 0, len(resp.Validations))
+			for _, v := range resp.Validations {
+				require.Contains(t, v.Detail, `"requiredvalue"`)
+				fields = append(fields, v.Field)
+			}
+			require.ElementsMatch(t, tc.invalid, fields, tc.name)
+		}
+	})
+
+	t.Run("RequiredValueNotOptional", func(t *testing.T) {
+		t.Parallel()
+		type request struct {
+			Count int `json:"count" validate:"requiredvalue"`
+		}
+		require.Panics(t, func() {
+			_ = httpapi.Validate.Struct(request{})
+		})
+	})
+
+	t.Run("TypeError", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"ttl":"soon"}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("Marshal", func(t *testing.T) {
+		t.Parallel()
+		b, err := json.Marshal(patchRequest{
+			Name:        httpapi.OptionalValue("dev"),
+			Description: httpapi.OptionalNull[string](),
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":"dev","description":null,"ttl":null}`, string(b))
+	})
+}
@@ -0,0 +1,204 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// TotalCountHeader carries the total number of rows matching a list request,
+// regardless of pagination. Browsers only expose it to cross-origin callers
+// when it's listed in Access-Control-Expose-Headers, which httpmw.Cors does.
+const TotalCountHeader = "X-Total-Count"
+
+// PaginationParams are the standard pagination query params of list
+// endpoints. Embed it in the struct passed to ReadQuery or ParseQuery, so
+// endpoints parse and validate them the same way.
+type PaginationParams struct {
+	AfterID uuid.UUID `query:"after_id"`
+	// Limit of 0 means no limit.
+	Limit  int32 `query:"limit" validate:"gte=0"`
+	Offset int32 `query:"offset" validate:"gte=0"`
+	// Cursor is where to continue a listing written with WritePage. It's
+	// zero on the first page.
+	Cursor Cursor `query:"cursor"`
+}
+
+// Pagination returns the params as codersdk.Pagination.
+func (p PaginationParams) Pagination() codersdk.Pagination {
+	return codersdk.Pagination{
+		AfterID: p.AfterID,
+		Limit:   int(p.Limit),
+		Offset:  int(p.Offset),
+	}
+}
+
+// SetPaginationLinks sets an RFC 5988 Link header pointing at the next and
+// previous pages, so clients can paginate without parsing the body. Each
+// relation's URL is base with the "offset" query param set to the given
+// value, and a nil offset omits that relation.
+func SetPaginationLinks(rw http.ResponseWriter, base *url.URL, next, prev *int) {
+	var rels []pageLink
+	if next != nil {
+		rels = append(rels, pageLink{rel: "next", value: strconv.Itoa(*next)})
+	}
+	if prev != nil {
+		rels = append(rels, pageLink{rel: "prev", value: strconv.Itoa(*prev)})
+	}
+	setLinks(rw, base, "offset", rels)
+}
+
+type pageLink struct {
+	rel   string
+	value string
+}
+
+// setLinks sets the Link header to the relations in links, each of which is
+// base with param set to the relation's value.
+func setLinks(rw http.ResponseWriter, base *url.URL, param string, links []pageLink, remove ...string) {
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		u := *base
+		query := u.Query()
+		for _, name := range remove {
+			query.Del(name)
+		}
+		query.Set(param, link.value)
+		u.RawQuery = query.Encode()
+		values = append(values, fmt.Sprintf("<%s>; rel=%q", u.String(), link.rel))
+	}
+	if len(values) == 0 {
+		return
+	}
+	rw.Header().Set("Link", strings.Join(values, ", "))
+}
+
+// Cursor is a position in a listing ordered by creation time, for keyset
+// pagination that stays fast on large tables where offsets don't. Clients
+// receive it as an opaque string and pass it back in the "cursor" query
+// param.
+type Cursor struct {
+	// ID of the last row of the previous page, breaking ties between rows
+	// created at the same time.
+	ID        uuid.UUID
+	CreatedAt time.Time
+}
+
+// IsZero returns true if c is the start of a listing.
+func (c Cursor) IsZero() bool {
+	return c.ID == uuid.Nil && c.CreatedAt.IsZero()
+}
+
+// EncodeCursor returns c as an opaque string. The zero cursor is "".
+func EncodeCursor(c Cursor) string {
+	if c.IsZero() {
+		return ""
+	}
+	raw := c.ID.String() + "," + c.CreatedAt.UTC().Format(time.RFC3339Nano)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a string returned by EncodeCursor. "" is the zero
+// cursor.
+func DecodeCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, xerrors.New("malformed cursor")
+	}
+	id, createdAt, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return Cursor{}, xerrors.New("malformed cursor")
+	}
+	var c Cursor
+	c.ID, err = uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, xerrors.New("malformed cursor")
+	}
+	c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, xerrors.New("malformed cursor")
+	}
+	return c, nil
+}
+
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(EncodeCursor(c)), nil
+}
+
+func (c *Cursor) UnmarshalText(text []byte) error {
+	parsed, err := DecodeCursor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// Page is the envelope of list responses written by WritePage.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// Count is the total number of matching rows across all pages.
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// PageCursors are the cursors of the pages around the one being written. A
+// zero cursor means there's no such page.
+type PageCursors struct {
+	Next Cursor
+	Prev Cursor
+}
+
+// WritePage writes items in a Page envelope, so list endpoints share one
+// shape. The total count is also set in the X-Total-Count header, and the
+// cursors in an RFC 5988 Link header of URLs that are the request's with the
+// "cursor" query param replaced. The "offset" and "after_id" params are
+// dropped from them, since cursors supersede them. Nil items are written as [].
+func WritePage[T any](rw http.ResponseWriter, r *http.Request, status int, items []T, cursors PageCursors, count int) {
+	if items == nil {
+		items = []T{}
+	}
+	page := Page[T]{
+		Items:      items,
+		Count:      count,
+		NextCursor: EncodeCursor(cursors.Next),
+		PrevCursor: EncodeCursor(cursors.Prev),
+	}
+
+	var links []pageLink
+	if page.NextCursor != "" {
+		links = append(links, pageLink{rel: "next", value: page.NextCursor})
+	}
+	if page.PrevCursor != "" {
+		links = append(links, pageLink{rel: "prev", value: page.PrevCursor})
+	}
+	setLinks(rw, r.URL, "cursor", links, "offset", "after_id")
+	rw.Header().Set(TotalCountHeader, strconv.Itoa(count))
+	Write(r.Context(), rw, status, page)
+}
+
+// WriteListWithCount writes results as a bare JSON array with a 200, and the
+// total number of matching rows in the X-Total-Count header so clients such as
+// UI grids can size themselves up front. A nil slice is written as [].
+func WriteListWithCount(rw http.ResponseWriter, total int, results any) {
+	if v := reflect.ValueOf(results); v.Kind() == reflect.Slice && v.IsNil() {
+		results = []struct{}{}
+	}
+	rw.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	Write(context.Background(), rw, http.StatusOK, results)
+}
@@ -0,0 +1,184 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestSetPaginationLinks(t *testing.T) {
+	t.Parallel()
+
+	base, err := url.Parse("https://coder.example.com/api/v2/workspaces?limit=25&offset=25")
+	require.NoError(t, err)
+	offset := func(i int) *int { return &i }
+
+	for _, tc := range []struct {
+		name     string
+		next     *int
+		prev     *int
+		expected string
+	}{
+		{
+			name:     "FirstPage",
+			next:     offset(25),
+			expected: `<https://coder.example.com/api/v2/workspaces?limit=25&offset=25>; rel="next"`,
+		},
+		{
+			name:     "MiddlePage",
+			next:     offset(50),
+			prev:     offset(0),
+			expected: `<https://coder.example.com/api/v2/workspaces?limit=25&offset=50>; rel="next", <https://coder.example.com/api/v2/workspaces?limit=25&offset=0>; rel="prev"`,
+		},
+		{
+			name:     "LastPage",
+			prev:     offset(25),
+			expected: `<https://coder.example.com/api/v2/workspaces?limit=25&offset=25>; rel="prev"`,
+		},
+		{
+			name: "OnlyPage",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			httpapi.SetPaginationLinks(rw, base, tc.next, tc.prev)
+			require.Equal(t, tc.expected, rw.Header().Get("Link"))
+		})
+	}
+
+	t.Run("BaseUnchanged", func(t *testing.T) {
+		t.Parallel()
+		httpapi.SetPaginationLinks(httptest.NewRecorder(), base, offset(50), nil)
+		require.Equal(t, "limit=25&offset=25", base.RawQuery)
+	})
+}
+
+func TestWriteListWithCount(t *testing.T) {
+	t.Parallel()
+
+	type workspace struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("Results", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		httpapi.WriteListWithCount(rw, 42, []workspace{{Name: "dev"}, {Name: "prod"}})
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "42", rw.Header().Get(httpapi.TotalCountHeader))
+		require.JSONEq(t, `[{"name":"dev"},{"name":"prod"}]`, rw.Body.String())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		var results []workspace
+		httpapi.WriteListWithCount(rw, 0, results)
+		require.Equal(t, "0", rw.Header().Get(httpapi.TotalCountHeader))
+		require.JSONEq(t, `[]`, rw.Body.String())
+	})
+}
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+		c := httpapi.Cursor{
+			ID:        uuid.New(),
+			CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.FixedZone("", 3600)),
+		}
+		encoded := httpapi.EncodeCursor(c)
+		require.NotContains(t, encoded, c.ID.String(), "cursors are opaque")
+		require.Equal(t, url.QueryEscape(encoded), encoded, "cursors are URL safe")
+
+		decoded, err := httpapi.DecodeCursor(encoded)
+		require.NoError(t, err)
+		require.Equal(t, c.ID, decoded.ID)
+		require.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	})
+
+	t.Run("Zero", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, httpapi.EncodeCursor(httpapi.Cursor{}))
+		c, err := httpapi.DecodeCursor("")
+		require.NoError(t, err)
+		require.True(t, c.IsZero())
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+		for _, s := range []string{"!!!", "bm9jb21tYQ", "bm90LWEtdXVpZCwyMDI0LTAxLTAxVDAwOjAwOjAwWg"} {
+			_, err := httpapi.DecodeCursor(s)
+			require.Error(t, err, s)
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		t.Parallel()
+		c := httpapi.Cursor{ID: uuid.New(), CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		r := httptest.NewRequest("GET", "/workspaces?limit=10&cursor="+httpapi.EncodeCursor(c), nil)
+		params, ok := httpapi.ParseQuery[httpapi.PaginationParams](httptest.NewRecorder(), r)
+		require.True(t, ok)
+		require.Equal(t, c, params.Cursor)
+		require.Equal(t, int32(10), params.Limit)
+
+		rw := httptest.NewRecorder()
+		r = httptest.NewRequest("GET", "/workspaces?cursor=bogus", nil)
+		_, ok = httpapi.ParseQuery[httpapi.PaginationParams](rw, r)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "cursor", resp.Validations[0].Field)
+	})
+}
+
+func TestWritePage(t *testing.T) {
+	t.Parallel()
+
+	type workspace struct {
+		Name string `json:"name"`
+	}
+	next := httpapi.Cursor{ID: uuid.New(), CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	prev := httpapi.Cursor{ID: uuid.New(), CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	t.Run("Page", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/workspaces?q=owner:me&limit=2&offset=4", nil)
+		httpapi.WritePage(rw, r, http.StatusOK, []workspace{{Name: "dev"}, {Name: "prod"}}, httpapi.PageCursors{Next: next, Prev: prev}, 42)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "42", rw.Header().Get(httpapi.TotalCountHeader))
+
+		var page httpapi.Page[workspace]
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&page))
+		require.Equal(t, []workspace{{Name: "dev"}, {Name: "prod"}}, page.Items)
+		require.Equal(t, 42, page.Count)
+		require.Equal(t, httpapi.EncodeCursor(next), page.NextCursor)
+		require.Equal(t, httpapi.EncodeCursor(prev), page.PrevCursor)
+
+		require.Equal(t, "</api/v2/workspaces?cursor="+page.NextCursor+"&limit=2&q=owner%3Ame>; rel=\"next\", "+
+			"</api/v2/workspaces?cursor="+page.PrevCursor+"&limit=2&q=owner%3Ame>; rel=\"prev\"", rw.Header().Get("Link"))
+	})
+
+	t.Run("LastPage", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/workspaces", nil)
+		httpapi.WritePage[workspace](rw, r, http.StatusOK, nil, httpapi.PageCursors{}, 0)
+		require.Empty(t, rw.Header().Get("Link"))
+		require.JSONEq(t, `{"items":[],"count":0}`, rw.Body.String())
+	})
+}
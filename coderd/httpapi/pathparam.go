@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// PathParam extracts the named path parameter from the request. It defaults
+// to chi's URL params, falling back to those matched by http.ServeMux, and
+// can be replaced during init for other routers.
+var PathParam = func(r *http.Request, name string) string {
+	if value := chi.URLParam(r, name); value != "" {
+		return value
+	}
+	return r.PathValue(name)
+}
+
+// MatchPathParam checks that bodyValue, such as an "id" decoded from the
+// request body, equals the paramName path parameter, e.g. "{id}" in
+// "/resources/{id}". On a mismatch a 400 is written and false is returned.
+func MatchPathParam(rw http.ResponseWriter, r *http.Request, bodyValue string, paramName string) bool {
+	param := PathParam(r, paramName)
+	if bodyValue == param {
+		return true
+	}
+	Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+		Message: "Request body doesn't match the URL.",
+		Validations: []codersdk.ValidationError{{
+			Field:  paramName,
+			Detail: fmt.Sprintf("the body has %q, but the path has %q", bodyValue, param),
+			Code:   "mismatch",
+		}},
+	})
+	return false
+}
+
+// ParseUUIDParam extracts the named path parameter with PathParam and parses
+// it as a UUID. A missing or malformed UUID is written as a 400 with an
+// "invalid_uuid" validation for the param, so handlers all reject them the
+// same way.
+func ParseUUIDParam(rw http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	raw := PathParam(r, name)
+	parsed, err := uuid.Parse(raw)
+	if err == nil {
+		return parsed, true
+	}
+	detail := fmt.Sprintf("%q is not a valid UUID", raw)
+	if raw == "" {
+		detail = "must be a UUID"
+	}
+	Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+		Message: "Invalid UUID in URL.",
+		Detail:  err.Error(),
+		Validations: []codersdk.ValidationError{{
+			Field:  name,
+			Detail: detail,
+			Code:   "invalid_uuid",
+		}},
+	})
+	return uuid.UUID{}, false
+}
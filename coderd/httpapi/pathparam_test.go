@@ -0,0 +1,111 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestMatchPathParam(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(id string) *http.Request {
+		r := httptest.NewRequest("PUT", "/resources/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		require.True(t, httpapi.MatchPathParam(rw, newRequest("abc"), "abc", "id"))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		require.False(t, httpapi.MatchPathParam(rw, newRequest("abc"), "def", "id"))
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "id", resp.Validations[0].Field)
+		require.Equal(t, "mismatch", resp.Validations[0].Code)
+	})
+
+	t.Run("ServeMux", func(t *testing.T) {
+		t.Parallel()
+		var matched bool
+		mux := http.NewServeMux()
+		mux.HandleFunc("PUT /resources/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			matched = httpapi.MatchPathParam(rw, r, "abc", "id")
+		})
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/resources/abc", nil))
+		require.True(t, matched)
+	})
+}
+
+func TestParseUUIDParam(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(id string) *http.Request {
+		r := httptest.NewRequest("GET", "/workspaces/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("workspace", id)
+		return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		id := uuid.New()
+		rw := httptest.NewRecorder()
+		parsed, ok := httpapi.ParseUUIDParam(rw, newRequest(id.String()), "workspace")
+		require.True(t, ok)
+		require.Equal(t, id, parsed)
+	})
+
+	for _, tc := range []struct {
+		name string
+		id   string
+	}{
+		{name: "Malformed", id: "not-a-uuid"},
+		{name: "Missing", id: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			_, ok := httpapi.ParseUUIDParam(rw, newRequest(tc.id), "workspace")
+			require.False(t, ok)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Len(t, resp.Validations, 1)
+			require.Equal(t, "workspace", resp.Validations[0].Field)
+			require.Equal(t, "invalid_uuid", resp.Validations[0].Code)
+		})
+	}
+
+	t.Run("ServeMux", func(t *testing.T) {
+		t.Parallel()
+		id := uuid.New()
+		var parsed uuid.UUID
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /workspaces/{workspace}", func(rw http.ResponseWriter, r *http.Request) {
+			parsed, _ = httpapi.ParseUUIDParam(rw, r, "workspace")
+		})
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/workspaces/"+id.String(), nil))
+		require.Equal(t, id, parsed)
+	})
+}
@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// WritePlainError is like Write for error responses, but renders a readable
+// plain text summary for clients that prefer text/plain over JSON, such as CLI
+// tools printing the body as-is. The summary holds the message, the detail,
+// and a line per validation error. JSON is written otherwise.
+func WritePlainError(rw http.ResponseWriter, r *http.Request, status int, response codersdk.Response) {
+	if !prefersPlainText(r) {
+		Write(r.Context(), rw, status, response)
+		return
+	}
+
+	_, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	var b strings.Builder
+	b.WriteString(response.Message + "\n")
+	if response.Detail != "" {
+		b.WriteString(response.Detail + "\n")
+	}
+	for _, v := range response.Validations {
+		b.WriteString("  " + v.Field + ": " + v.Detail + "\n")
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(status)
+	_, _ = rw.Write([]byte(b.String()))
+}
+
+// prefersPlainText reports whether the request's Accept header ranks
+// text/plain above application/json.
+func prefersPlainText(r *http.Request) bool {
+	var plainQ, jsonQ float64
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if raw, ok := params["q"]; ok {
+				q, err = strconv.ParseFloat(raw, 64)
+				if err != nil {
+					continue
+				}
+			}
+			switch mediaType {
+			case "text/plain", "text/*":
+				plainQ = max(plainQ, q)
+			case "application/json", "application/*":
+				jsonQ = max(jsonQ, q)
+			case "*/*":
+				plainQ = max(plainQ, q)
+				jsonQ = max(jsonQ, q)
+			}
+		}
+	}
+	return plainQ > jsonQ
+}
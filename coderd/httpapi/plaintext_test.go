@@ -0,0 +1,58 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestWritePlainError(t *testing.T) {
+	t.Parallel()
+
+	response := codersdk.Response{
+		Message: "Validation failed.",
+		Detail:  "Check the fields below.",
+		Validations: []codersdk.ValidationError{
+			{Field: "name", Detail: "required"},
+			{Field: "ttl_ms", Detail: "must be positive"},
+		},
+	}
+	write := func(accept string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		httpapi.WritePlainError(rw, r, http.StatusBadRequest, response)
+		return rw
+	}
+
+	for _, accept := range []string{"text/plain", "application/json;q=0.5, text/plain"} {
+		t.Run("PlainText", func(t *testing.T) {
+			t.Parallel()
+			rw := write(accept)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			require.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+			require.Equal(t, "Validation failed.\nCheck the fields below.\n  name: required\n  ttl_ms: must be positive\n", rw.Body.String())
+		})
+	}
+
+	for _, accept := range []string{"", "application/json", "*/*", "text/plain;q=0.5, application/json"} {
+		t.Run("JSON", func(t *testing.T) {
+			t.Parallel()
+			rw := write(accept)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			require.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+
+			var got codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&got))
+			require.Equal(t, response, got)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type readProgressKey struct{}
+
+type readProgress struct {
+	every int64
+	fn    func(bytesRead int64)
+}
+
+// WithReadProgress returns a context that makes Read, ReadMultipart and
+// ReadFile report how many bytes of the request body they have consumed. fn
+// is called each time at least every more bytes have been read, and once more
+// when reading finishes. This lets handlers accepting large uploads emit
+// progress to logs or metrics.
+func WithReadProgress(ctx context.Context, every int64, fn func(bytesRead int64)) context.Context {
+	if every < 1 {
+		every = 1
+	}
+	return context.WithValue(ctx, readProgressKey{}, readProgress{every: every, fn: fn})
+}
+
+// progressReader counts the bytes read through it and reports them to fn.
+type progressReader struct {
+	r        io.Reader
+	progress readProgress
+	read     int64
+	reported int64
+}
+
+// withReadProgress wraps r in a progressReader if ctx was configured with
+// WithReadProgress. The returned func reports the final count and must be
+// called once reading is done.
+func withReadProgress(ctx context.Context, r io.Reader) (io.Reader, func()) {
+	if ctx == nil || r == nil {
+		return r, func() {}
+	}
+	progress, ok := ctx.Value(readProgressKey{}).(readProgress)
+	if !ok || progress.fn == nil {
+		return r, func() {}
+	}
+	pr := &progressReader{r: r, progress: progress}
+	return pr, pr.flush
+}
+
+// withBodyProgress is like withReadProgress, but replaces r.Body so readers
+// of r, such as its MultipartReader, are counted too.
+func withBodyProgress(ctx context.Context, r *http.Request) func() {
+	if r.Body == nil {
+		return func() {}
+	}
+	body, done := withReadProgress(ctx, r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+	return done
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.read-p.reported >= p.progress.every {
+		p.flush()
+	}
+	return n, err
+}
+
+func (p *progressReader) flush() {
+	if p.read == p.reported {
+		return
+	}
+	p.reported = p.read
+	p.progress.fn(p.read)
+}
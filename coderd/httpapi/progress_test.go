@@ -0,0 +1,40 @@
+package httpapi_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestWithReadProgress(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Context string `json:"context"`
+	}
+	body := `{"context":"` + strings.Repeat("a", 100) + `"}`
+
+	var counts []int64
+	ctx := httpapi.WithReadProgress(context.Background(), 16, func(bytesRead int64) {
+		counts = append(counts, bytesRead)
+	})
+	rw := httptest.NewRecorder()
+	// Deliver the body a byte at a time, as a slow chunked upload would.
+	r := httptest.NewRequest("POST", "/", iotest.OneByteReader(strings.NewReader(body)))
+
+	var v request
+	require.True(t, httpapi.Read(ctx, rw, r, &v))
+	require.Len(t, v.Context, 100)
+
+	require.Greater(t, len(counts), 1)
+	for i := 1; i < len(counts); i++ {
+		require.Greater(t, counts[i], counts[i-1])
+	}
+	require.Equal(t, int64(len(body)), counts[len(counts)-1])
+}
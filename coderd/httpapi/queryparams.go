@@ -122,6 +122,25 @@ func (p *QueryParamParser) RequiredNotEmpty(queryParam ...string) *QueryParamPar
 	return p
 }
 
+// RequirePair adds an error if only one of the two query params is provided,
+// e.g. a time range filter that needs both "start" and "end" or neither.
+func (p *QueryParamParser) RequirePair(vals url.Values, first, second string) *QueryParamParser {
+	hasFirst, hasSecond := queryParamPresent(vals, first), queryParamPresent(vals, second)
+	switch {
+	case hasFirst && !hasSecond:
+		p.Errors = append(p.Errors, codersdk.ValidationError{
+			Field:  second,
+			Detail: fmt.Sprintf("Query param %q is required when %q is provided", second, first),
+		})
+	case hasSecond && !hasFirst:
+		p.Errors = append(p.Errors, codersdk.ValidationError{
+			Field:  first,
+			Detail: fmt.Sprintf("Query param %q is required when %q is provided", first, second),
+		})
+	}
+	return p
+}
+
 func (p *QueryParamParser) UUIDorMe(vals url.Values, def uuid.UUID, me uuid.UUID, queryParam string) uuid.UUID {
 	return ParseCustom(p, vals, def, queryParam, func(v string) (uuid.UUID, error) {
 		if v == "me" {
@@ -294,6 +313,26 @@ func ParseCustomList[T any](parser *QueryParamParser, vals url.Values, def []T,
 	return v
 }
 
+// RequireOrder adds an error if both query params are provided and the parsed
+// start value is after the end value. compare follows the convention of
+// cmp.Compare and time.Time.Compare. It has to be a function for the same
+// reason as ParseCustom.
+func RequireOrder[T any](parser *QueryParamParser, vals url.Values, startParam string, start T, endParam string, end T, compare func(a, b T) int) {
+	if !queryParamPresent(vals, startParam) || !queryParamPresent(vals, endParam) {
+		return
+	}
+	if compare(start, end) > 0 {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  startParam,
+			Detail: fmt.Sprintf("Query param %q must not be after %q", startParam, endParam),
+		})
+	}
+}
+
+func queryParamPresent(vals url.Values, queryParam string) bool {
+	return vals.Has(queryParam) && vals.Get(queryParam) != ""
+}
+
 // parseQueryParam expects just 1 value set for the given query param.
 func parseQueryParam[T any](parser *QueryParamParser, vals url.Values, parse func(v string) (T, error), def T, queryParam string) (T, error) {
 	setParse := func(set []string) (T, error) {
@@ -462,3 +462,46 @@ func testQueryParams[T any](t *testing.T, testCases []queryParamTestCase[T], par
 		})
 	}
 }
+
+func TestQueryParamPairs(t *testing.T) {
+	t.Parallel()
+
+	parse := func(t *testing.T, query string) *httpapi.QueryParamParser {
+		vals, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		parser := httpapi.NewQueryParamParser()
+		start := parser.Time3339Nano(vals, time.Time{}, "start")
+		end := parser.Time3339Nano(vals, time.Time{}, "end")
+		parser.RequirePair(vals, "start", "end")
+		httpapi.RequireOrder(parser, vals, "start", start, "end", end, time.Time.Compare)
+		return parser
+	}
+
+	t.Run("BothPresent", func(t *testing.T) {
+		t.Parallel()
+		parser := parse(t, "start=2024-01-01T00:00:00Z&end=2024-02-01T00:00:00Z")
+		require.Empty(t, parser.Errors)
+	})
+
+	t.Run("Neither", func(t *testing.T) {
+		t.Parallel()
+		parser := parse(t, "")
+		require.Empty(t, parser.Errors)
+	})
+
+	t.Run("OnlyStart", func(t *testing.T) {
+		t.Parallel()
+		parser := parse(t, "start=2024-01-01T00:00:00Z")
+		require.Len(t, parser.Errors, 1)
+		require.Equal(t, "end", parser.Errors[0].Field)
+		require.Contains(t, parser.Errors[0].Detail, `"end" is required when "start" is provided`)
+	})
+
+	t.Run("StartAfterEnd", func(t *testing.T) {
+		t.Parallel()
+		parser := parse(t, "start=2024-02-01T00:00:00Z&end=2024-01-01T00:00:00Z")
+		require.Len(t, parser.Errors, 1)
+		require.Equal(t, "start", parser.Errors[0].Field)
+		require.Contains(t, parser.Errors[0].Detail, `must not be after "end"`)
+	})
+}
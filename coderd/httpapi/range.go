@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ServeRange writes content to the response, honoring a single byte range in
+// the request's Range header with a 206 and Content-Range. An unsatisfiable
+// range is rejected with a 416. Requests without a valid Range header get the
+// full content with a 200.
+//
+// Only single ranges are supported. If several are requested, only the first
+// one is served, which clients must handle per RFC 9110.
+func ServeRange(rw http.ResponseWriter, r *http.Request, content io.ReadSeeker, contentType string) {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		InternalServerError(rw, err)
+		return
+	}
+
+	h := rw.Header()
+	h.Set("Accept-Ranges", "bytes")
+
+	start, end, ok := parseRange(r.Header.Get("Range"), size)
+	if !ok {
+		h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		Write(r.Context(), rw, http.StatusRequestedRangeNotSatisfiable, codersdk.Response{
+			Message: "Requested range is not satisfiable.",
+			Detail:  fmt.Sprintf("Content is %d bytes.", size),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if start != 0 || end != size-1 {
+		status = http.StatusPartialContent
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	}
+
+	_, err = content.Seek(start, io.SeekStart)
+	if err != nil {
+		InternalServerError(rw, err)
+		return
+	}
+	length := end - start + 1
+	if size == 0 {
+		length = 0
+	}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.FormatInt(length, 10))
+	rw.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	// We can't really do much about these errors, it's probably due to a
+	// dropped connection.
+	_, _ = io.CopyN(rw, content, length)
+}
+
+// parseRange returns the inclusive byte range requested by header for content
+// of the given size. A missing or malformed header selects the full content.
+// False is returned if the range is unsatisfiable.
+func parseRange(header string, size int64) (start, end int64, ok bool) {
+	full := func() (int64, int64, bool) { return 0, size - 1, true }
+
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found {
+		return full()
+	}
+	spec, _, _ = strings.Cut(spec, ",")
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return full()
+	}
+
+	if first == "" {
+		// Suffix range, e.g. "-500" for the last 500 bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return full()
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return full()
+	}
+	end = size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return full()
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false
+	}
+	return start, end, true
+}
@@ -0,0 +1,85 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestServeRange(t *testing.T) {
+	t.Parallel()
+
+	const content = "0123456789"
+
+	for _, tc := range []struct {
+		name         string
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{
+			name:   "Full",
+			status: http.StatusOK,
+			body:   content,
+		},
+		{
+			name:         "Satisfiable",
+			rangeHeader:  "bytes=2-5",
+			status:       http.StatusPartialContent,
+			body:         "2345",
+			contentRange: "bytes 2-5/10",
+		},
+		{
+			name:         "OpenEnded",
+			rangeHeader:  "bytes=7-",
+			status:       http.StatusPartialContent,
+			body:         "789",
+			contentRange: "bytes 7-9/10",
+		},
+		{
+			name:         "Suffix",
+			rangeHeader:  "bytes=-3",
+			status:       http.StatusPartialContent,
+			body:         "789",
+			contentRange: "bytes 7-9/10",
+		},
+		{
+			name:         "MultiRangeServesFirst",
+			rangeHeader:  "bytes=0-1, 4-5",
+			status:       http.StatusPartialContent,
+			body:         "01",
+			contentRange: "bytes 0-1/10",
+		},
+		{
+			name:         "OutOfBounds",
+			rangeHeader:  "bytes=20-30",
+			status:       http.StatusRequestedRangeNotSatisfiable,
+			contentRange: "bytes */10",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.rangeHeader != "" {
+				r.Header.Set("Range", tc.rangeHeader)
+			}
+			httpapi.ServeRange(rw, r, strings.NewReader(content), "text/plain")
+
+			require.Equal(t, tc.status, rw.Code)
+			require.Equal(t, tc.contentRange, rw.Header().Get("Content-Range"))
+			if tc.status == http.StatusRequestedRangeNotSatisfiable {
+				require.Contains(t, rw.Header().Get("Content-Type"), "application/json")
+				return
+			}
+			require.Equal(t, tc.body, rw.Body.String())
+			require.Equal(t, "text/plain", rw.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// maxRawBodySize is the largest body ReadWithRaw buffers in memory.
+const maxRawBodySize = 10 << 20
+
+// ReadWithRaw is like Read, but buffers the request body and returns the raw
+// bytes alongside the decoded value. This is needed when the exact body must
+// be inspected after decoding, such as to verify a webhook signature. Bodies
+// larger than 10 MiB are rejected with a 413.
+func ReadWithRaw(rw http.ResponseWriter, r *http.Request, value interface{}) ([]byte, bool) {
+	raw, ok := readRawBody(rw, r)
+	if !ok {
+		return nil, false
+	}
+	if !Read(r.Context(), rw, r, value) {
+		return nil, false
+	}
+	return raw, true
+}
+
+// ReadLimited is like Read, but rejects bodies larger than maxBytes with a
+// 413. The limit is enforced while reading rather than by checking
+// Content-Length, so it also applies to chunked bodies, which don't have one.
+func ReadLimited(ctx context.Context, rw http.ResponseWriter, r *http.Request, value interface{}, maxBytes int64) bool {
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(rw, r.Body, maxBytes)
+	}
+	return Read(ctx, rw, r, value)
+}
+
+// writeBodyTooLarge writes a 413 and returns true if err is from reading past
+// the limit of an http.MaxBytesReader.
+func writeBodyTooLarge(ctx context.Context, rw http.ResponseWriter, err error) bool {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return false
+	}
+	Write(ctx, rw, http.StatusRequestEntityTooLarge, codersdk.Response{
+		Message: "Request body is too large.",
+		Detail:  fmt.Sprintf("The request body must not exceed %d bytes.", maxErr.Limit),
+	})
+	return true
+}
+
+// readRawBody buffers the request body, replacing r.Body so it can be read
+// again. On failure the error is written to rw.
+func readRawBody(rw http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	ctx := r.Context()
+
+	var raw []byte
+	if r.Body != nil {
+		var err error
+		raw, err = io.ReadAll(http.MaxBytesReader(rw, r.Body, maxRawBodySize))
+		if err != nil {
+			if writeBodyTooLarge(ctx, rw, err) {
+				return nil, false
+			}
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to read request body.",
+				Detail:  err.Error(),
+			})
+			return nil, false
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, true
+}
@@ -0,0 +1,106 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadWithRaw(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Event string `json:"event" validate:"required"`
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		// Formatting the raw bytes must survive exactly as sent.
+		body := []byte("{\n  \"event\": \"push\"\n}")
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+
+		var v request
+		raw, ok := httpapi.ReadWithRaw(rw, r, &v)
+		require.True(t, ok)
+		require.Equal(t, body, raw)
+		require.Equal(t, "push", v.Event)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{}`))
+
+		var v request
+		raw, ok := httpapi.ReadWithRaw(rw, r, &v)
+		require.False(t, ok)
+		require.Nil(t, raw)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		body := io.MultiReader(strings.NewReader(`{"event":"`), strings.NewReader(strings.Repeat("a", 11<<20)), strings.NewReader(`"}`))
+		r := httptest.NewRequest("POST", "/", body)
+
+		var v request
+		_, ok := httpapi.ReadWithRaw(rw, r, &v)
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+}
+
+func TestReadLimited(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Names []string `json:"names"`
+	}
+	post := func(t *testing.T, names int) (*http.Response, []string) {
+		var transferEncoding []string
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			transferEncoding = r.TransferEncoding
+			var v request
+			if httpapi.ReadLimited(r.Context(), rw, r, &v, 1024) {
+				rw.WriteHeader(http.StatusNoContent)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		body := `{"names":[` + strings.Repeat(`"coder",`, names) + `"coder"]}`
+		// Wrapping the body hides its length, so it's sent chunked.
+		res, err := srv.Client().Post(srv.URL, "application/json", io.MultiReader(strings.NewReader(body)))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res, transferEncoding
+	}
+
+	t.Run("WithinLimit", func(t *testing.T) {
+		t.Parallel()
+		res, transferEncoding := post(t, 10)
+		require.Equal(t, []string{"chunked"}, transferEncoding)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("ChunkedOverLimit", func(t *testing.T) {
+		t.Parallel()
+		res, transferEncoding := post(t, 1000)
+		require.Equal(t, []string{"chunked"}, transferEncoding)
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		require.Equal(t, "Request body is too large.", resp.Message)
+	})
+}
@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// RedactedValue replaces the value of every string field tagged with
+// `redact:"true"` in responses written by WriteRedacted.
+const RedactedValue = "***"
+
+// WriteRedacted is like Write, but masks every string field tagged with
+// `redact:"true"` before encoding. Unlike `json:"-"`, the field is still
+// present in the output, so clients can tell it is set. This is a safety net
+// for structs that are shared between internal and external contexts.
+//
+// Nested structs, pointers, slices, maps and interfaces are walked. The
+// original value is never modified.
+func WriteRedacted(ctx context.Context, rw http.ResponseWriter, status int, response interface{}) {
+	if response != nil {
+		response = redact(reflect.ValueOf(response)).Interface()
+	}
+	Write(ctx, rw, status, response)
+}
+
+// redact returns a copy of v with all redact-tagged string fields masked.
+func redact(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(redact(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(redact(v.Elem()))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		// Copy unexported fields as-is, since they can't be set individually.
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get("redact") == "true" {
+				redactField(out.Field(i))
+				continue
+			}
+			out.Field(i).Set(redact(v.Field(i)))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redact(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redact(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), redact(iter.Value()))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// redactField masks a redact-tagged field. Empty values are left empty so
+// clients can still distinguish unset fields.
+func redactField(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() > 0 {
+			v.SetString(RedactedValue)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() && v.Elem().Kind() == reflect.String {
+			masked := reflect.New(v.Type().Elem())
+			masked.Elem().SetString(RedactedValue)
+			v.Set(masked)
+		}
+	}
+}
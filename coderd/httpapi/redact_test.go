@@ -0,0 +1,58 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestWriteRedacted(t *testing.T) {
+	t.Parallel()
+
+	type credentials struct {
+		Username string `json:"username"`
+		Token    string `json:"token" redact:"true"`
+	}
+	type response struct {
+		Name        string        `json:"name"`
+		Secret      string        `json:"secret" redact:"true"`
+		Empty       string        `json:"empty" redact:"true"`
+		Credentials credentials   `json:"credentials"`
+		Pointer     *credentials  `json:"pointer"`
+		List        []credentials `json:"list"`
+	}
+
+	value := response{
+		Name:        "coder",
+		Secret:      "hunter2",
+		Credentials: credentials{Username: "admin", Token: "abc"},
+		Pointer:     &credentials{Username: "ptr", Token: "def"},
+		List:        []credentials{{Username: "item", Token: "ghi"}},
+	}
+
+	rw := httptest.NewRecorder()
+	httpapi.WriteRedacted(context.Background(), rw, http.StatusOK, value)
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	var got response
+	err := json.NewDecoder(rw.Body).Decode(&got)
+	require.NoError(t, err)
+	require.Equal(t, response{
+		Name:        "coder",
+		Secret:      httpapi.RedactedValue,
+		Credentials: credentials{Username: "admin", Token: httpapi.RedactedValue},
+		Pointer:     &credentials{Username: "ptr", Token: httpapi.RedactedValue},
+		List:        []credentials{{Username: "item", Token: httpapi.RedactedValue}},
+	}, got)
+
+	// The original value must be left untouched.
+	require.Equal(t, "hunter2", value.Secret)
+	require.Equal(t, "def", value.Pointer.Token)
+	require.Equal(t, "ghi", value.List[0].Token)
+}
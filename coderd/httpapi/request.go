@@ -1,6 +1,12 @@
 package httpapi
 
-import "net/http"
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
 
 const (
 	// XForwardedHostHeader is a header used by proxies to indicate the
@@ -28,3 +34,50 @@ func IsWebsocketUpgrade(r *http.Request) bool {
 	}
 	return false
 }
+
+// NegotiateVersion picks the API version to respond with from the "version"
+// parameter of the request's Accept media types, e.g.
+// "application/json; version=2". The highest supported version the client
+// accepts is returned. If the client doesn't ask for a version, the lowest
+// supported version is returned.
+//
+// False is returned if the client only accepts unsupported versions, in which
+// case the handler should respond with a 406.
+func NegotiateVersion(r *http.Request, supported ...int) (int, bool) {
+	if len(supported) == 0 {
+		return 0, false
+	}
+
+	var (
+		requested bool
+		best      = -1
+	)
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			_, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			raw, ok := params["version"]
+			if !ok {
+				continue
+			}
+			requested = true
+			version, err := strconv.Atoi(raw)
+			if err != nil {
+				continue
+			}
+			if slices.Contains(supported, version) && version > best {
+				best = version
+			}
+		}
+	}
+
+	if !requested {
+		return slices.Min(supported), true
+	}
+	if best < 0 {
+		return 0, false
+	}
+	return best, true
+}
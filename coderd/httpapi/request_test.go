@@ -0,0 +1,61 @@
+package httpapi_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		accept  []string
+		version int
+		ok      bool
+	}{
+		{
+			name:    "Explicit",
+			accept:  []string{"application/json; version=2"},
+			version: 2,
+			ok:      true,
+		},
+		{
+			name:    "Unspecified",
+			accept:  []string{"application/json"},
+			version: 1,
+			ok:      true,
+		},
+		{
+			name:    "NoHeader",
+			version: 1,
+			ok:      true,
+		},
+		{
+			name:    "HighestSupported",
+			accept:  []string{"application/json; version=1, application/json; version=2;q=0.9, application/json; version=3"},
+			version: 2,
+			ok:      true,
+		},
+		{
+			name:   "Unsupported",
+			accept: []string{"application/json; version=3"},
+			ok:     false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/", nil)
+			for _, accept := range tc.accept {
+				r.Header.Add("Accept", accept)
+			}
+			version, ok := httpapi.NegotiateVersion(r, 1, 2)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.version, version)
+		})
+	}
+}
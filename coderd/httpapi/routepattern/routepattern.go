@@ -0,0 +1,61 @@
+// Package routepattern shares the pattern of the route matching a request
+// between the router, which learns it last, and the code labeling logs and
+// metrics by it, such as httpmw's middleware and httpapi's response metrics,
+// without either depending on the other.
+package routepattern
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type contextKey struct{}
+
+// holder holds the pattern of the route matching a request. Routers set it
+// further down the chain than the code reading it, so it's shared by pointer
+// rather than stored in the context directly.
+type holder struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+// WithHolder returns ctx with a place for Set to record the pattern in. If ctx
+// already has one, it's returned as is.
+func WithHolder(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(contextKey{}).(*holder); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, &holder{})
+}
+
+// Set records the pattern of the route matching the request. It does nothing
+// if ctx wasn't returned by WithHolder.
+func Set(ctx context.Context, pattern string) {
+	h, ok := ctx.Value(contextKey{}).(*holder)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pattern = pattern
+}
+
+// FromContext returns the pattern of the route matching the request, or an
+// empty string if it isn't known (yet). Patterns recorded with Set take
+// precedence over the one matched by chi.
+func FromContext(ctx context.Context) string {
+	if h, ok := ctx.Value(contextKey{}).(*holder); ok {
+		h.mu.Lock()
+		pattern := h.pattern
+		h.mu.Unlock()
+		if pattern != "" {
+			return pattern
+		}
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
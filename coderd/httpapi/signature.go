@@ -0,0 +1,37 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// VerifyHMAC checks that the named header holds the hex HMAC-SHA256 of raw
+// keyed with secret, optionally prefixed with "sha256=" as GitHub and others
+// send it. raw should be the exact body, e.g. as returned by ReadWithRaw. On
+// a missing or mismatched signature a 401 is written and false is returned.
+func VerifyHMAC(rw http.ResponseWriter, r *http.Request, raw []byte, secret []byte, header string) bool {
+	signature := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(header)), "sha256=")
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(got) == 0 {
+		Write(r.Context(), rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "invalid signature",
+			Detail:  "The " + header + " header must contain a hex encoded HMAC-SHA256 signature.",
+		})
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(raw)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		Write(r.Context(), rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "invalid signature",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package httpapi_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestVerifyHMAC(t *testing.T) {
+	t.Parallel()
+
+	const header = "X-Signature-256"
+	secret := []byte("webhook-secret")
+	body := []byte(`{"event":"push"}`)
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	for _, tc := range []struct {
+		name      string
+		signature string
+		body      string
+		valid     bool
+	}{
+		{name: "Valid", signature: signature, body: string(body), valid: true},
+		{name: "Prefixed", signature: "sha256=" + signature, body: string(body), valid: true},
+		{name: "Tampered", signature: "sha256=" + signature, body: `{"event":"delete"}`},
+		{name: "Missing", body: string(body)},
+		{name: "NotHex", signature: "sha256=nope", body: string(body)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", nil)
+			if tc.signature != "" {
+				r.Header.Set(header, tc.signature)
+			}
+			ok := httpapi.VerifyHMAC(rw, r, []byte(tc.body), secret, header)
+			require.Equal(t, tc.valid, ok)
+			if !tc.valid {
+				require.Equal(t, http.StatusUnauthorized, rw.Code)
+				require.Contains(t, rw.Body.String(), "invalid signature")
+			}
+		})
+	}
+}
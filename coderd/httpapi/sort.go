@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// SortField is a single column to order results by.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ParseSort parses the "sort" query param, a comma-separated list of fields
+// where a leading "-" sorts descending, e.g. "?sort=name,-created_at". Each
+// field is looked up in allowed, which maps the public field name to the
+// column to sort by, so unchecked input never reaches a query. If a field is
+// unknown the error is written to rw and ok is false. No sort param returns no
+// fields.
+func ParseSort(rw http.ResponseWriter, r *http.Request, allowed map[string]string) ([]SortField, bool) {
+	raw := r.URL.Query().Get("sort")
+	if raw == "" {
+		return nil, true
+	}
+
+	var (
+		fields []SortField
+		errs   []codersdk.ValidationError
+		seen   = map[string]bool{}
+	)
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		name, desc := strings.CutPrefix(term, "-")
+		column, ok := allowed[name]
+		switch {
+		case name == "":
+			errs = append(errs, codersdk.ValidationError{
+				Field:  "sort",
+				Detail: fmt.Sprintf("Query param %q must not contain empty fields", "sort"),
+				Code:   "invalid_sort",
+			})
+		case !ok:
+			errs = append(errs, codersdk.ValidationError{
+				Field:  "sort",
+				Detail: fmt.Sprintf("%q is not a sortable field", name),
+				Code:   "invalid_sort",
+			})
+		case seen[name]:
+			errs = append(errs, codersdk.ValidationError{
+				Field:  "sort",
+				Detail: fmt.Sprintf("%q is sorted by more than once", name),
+				Code:   "invalid_sort",
+			})
+		default:
+			seen[name] = true
+			fields = append(fields, SortField{Column: column, Desc: desc})
+		}
+	}
+	if len(errs) > 0 {
+		Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: errs,
+		})
+		return nil, false
+	}
+	return fields, true
+}
@@ -0,0 +1,68 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestParseSort(t *testing.T) {
+	t.Parallel()
+
+	allowed := map[string]string{
+		"name":       "workspaces.name",
+		"created_at": "workspaces.created_at",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		expected []httpapi.SortField
+	}{
+		{name: "None", query: ""},
+		{name: "Ascending", query: "?sort=name", expected: []httpapi.SortField{{Column: "workspaces.name"}}},
+		{name: "Descending", query: "?sort=-created_at", expected: []httpapi.SortField{{Column: "workspaces.created_at", Desc: true}}},
+		{name: "Multiple", query: "?sort=name,-created_at", expected: []httpapi.SortField{
+			{Column: "workspaces.name"},
+			{Column: "workspaces.created_at", Desc: true},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/"+tc.query, nil)
+			fields, ok := httpapi.ParseSort(rw, r, allowed)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, fields)
+		})
+	}
+
+	for _, query := range []string{
+		"?sort=owner_id",
+		"?sort=name%3B%20DROP%20TABLE%20users",
+		"?sort=name,,created_at",
+		"?sort=name,-name",
+	} {
+		t.Run("Rejected", func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/"+query, nil)
+			fields, ok := httpapi.ParseSort(rw, r, allowed)
+			require.False(t, ok)
+			require.Nil(t, fields)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Len(t, resp.Validations, 1)
+			require.Equal(t, "sort", resp.Validations[0].Field)
+			require.Equal(t, "invalid_sort", resp.Validations[0].Code)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// defaultLocale is used when a request doesn't ask for a supported locale.
+// Validations registered by httpapi describe their failures in it, so
+// translations only replace those descriptions for other locales.
+const defaultLocale = "en"
+
+// translators holds the locales validation errors are translated to, picked
+// from the Accept-Language header of the request.
+var translators *ut.UniversalTranslator
+
+func registerTranslations() {
+	supported := []struct {
+		locale   locales.Translator
+		register func(*validator.Validate, ut.Translator) error
+	}{
+		{locale: en.New(), register: en_translations.RegisterDefaultTranslations},
+		{locale: es.New(), register: es_translations.RegisterDefaultTranslations},
+		{locale: fr.New(), register: fr_translations.RegisterDefaultTranslations},
+		{locale: ja.New(), register: ja_translations.RegisterDefaultTranslations},
+		{locale: pt_BR.New(), register: pt_BR_translations.RegisterDefaultTranslations},
+		{locale: zh.New(), register: zh_translations.RegisterDefaultTranslations},
+	}
+	all := make([]locales.Translator, 0, len(supported))
+	for _, s := range supported {
+		all = append(all, s.locale)
+	}
+	translators = ut.New(all[0], all...)
+	for _, s := range supported {
+		trans, _ := translators.GetTranslator(s.locale.Locale())
+		err := s.register(Validate, trans)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTranslation registers text as the description of failures of tag
+// for locale, such as "fr" or "pt_BR", in the Detail of validation errors.
+// "{0}" in text is replaced by the field name and "{1}" by the tag param. For
+// example:
+//
+//	httpapi.RegisterTranslation("fr", "cron", "{0} doit être une expression cron valide")
+//
+// It panics if locale isn't supported. Like all registrations, it isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterTranslation(locale, tag, text string) {
+	registrationMu.Lock()
+	defer registrationMu.Unlock()
+
+	trans, found := translators.GetTranslator(locale)
+	if !found {
+		panic("unsupported translation locale " + strconv.Quote(locale))
+	}
+	err := Validate.RegisterTranslation(tag, trans, func(trans ut.Translator) error {
+		return trans.Add(tag, text, true)
+	}, func(trans ut.Translator, fe validator.FieldError) string {
+		translated, err := trans.T(tag, fe.Field(), fe.Param())
+		if err != nil {
+			return fe.Error()
+		}
+		return translated
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// requestTranslator returns the translator for the most preferred supported
+// locale in the Accept-Language header of r, falling back to defaultLocale.
+// Regional locales fall back to their language, e.g. "fr-CA" to "fr".
+func requestTranslator(r *http.Request) ut.Translator {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	if r != nil {
+		for _, header := range r.Header.Values("Accept-Language") {
+			for _, part := range strings.Split(header, ",") {
+				tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+				q := 1.0
+				if raw, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+					parsed, err := strconv.ParseFloat(raw, 64)
+					if err != nil {
+						continue
+					}
+					q = parsed
+				}
+				if tag == "" || tag == "*" || q <= 0 {
+					continue
+				}
+				languages = append(languages, language{tag: tag, q: q})
+			}
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	candidates := make([]string, 0, len(languages)*2)
+	for _, l := range languages {
+		tag := strings.ReplaceAll(l.tag, "-", "_")
+		candidates = append(candidates, tag)
+		if base, _, ok := strings.Cut(tag, "_"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	for _, candidate := range candidates {
+		if trans, found := translators.GetTranslator(candidate); found {
+			return trans
+		}
+	}
+	trans, _ := translators.GetTranslator(defaultLocale)
+	return trans
+}
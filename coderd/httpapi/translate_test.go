@@ -0,0 +1,92 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestTranslation(t *testing.T) {
+	httpapi.RegisterReserved("test_translated_reserved", "admin")
+	httpapi.RegisterTranslation("fr", "test_translated_reserved", "{0} est un nom réservé")
+	t.Parallel()
+
+	type request struct {
+		Name     string `json:"name" validate:"required,max=8"`
+		Nickname string `json:"nickname" validate:"omitempty,test_translated_reserved"`
+	}
+	detail := func(t *testing.T, acceptLanguage, body string) string {
+		t.Helper()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+		if acceptLanguage != "" {
+			r.Header.Set("Accept-Language", acceptLanguage)
+		}
+		require.False(t, httpapi.Read(context.Background(), rw, r, &request{}))
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		return resp.Validations[0].Detail
+	}
+	const tooLong = `{"name":"developer-workspace"}`
+
+	for _, tc := range []struct {
+		name           string
+		acceptLanguage string
+		body           string
+		expected       string
+	}{
+		{
+			name:     "Default",
+			body:     tooLong,
+			expected: `name must be a maximum of 8 characters in length`,
+		},
+		{
+			name:           "French",
+			acceptLanguage: "fr",
+			body:           tooLong,
+			expected:       `name doit faire une taille maximum de 8 caractères`,
+		},
+		{
+			name:           "RegionFallsBackToLanguage",
+			acceptLanguage: "fr-CA",
+			body:           tooLong,
+			expected:       `name doit faire une taille maximum de 8 caractères`,
+		},
+		{
+			name:           "Preference",
+			acceptLanguage: "de-DE, fr;q=0.5, es;q=0.8",
+			body:           `{}`,
+			expected:       `name es un campo requerido`,
+		},
+		{
+			name:           "Unsupported",
+			acceptLanguage: "de",
+			body:           `{}`,
+			expected:       `name is a required field`,
+		},
+		{
+			name:     "CustomTagDefault",
+			body:     `{"name":"dev","nickname":"admin"}`,
+			expected: `Validation failed for tag "test_translated_reserved" with value: "admin": is a reserved name`,
+		},
+		{
+			name:           "CustomTagTranslated",
+			acceptLanguage: "fr-FR,fr;q=0.9",
+			body:           `{"name":"dev","nickname":"admin"}`,
+			expected:       `nickname est un nom réservé`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, detail(t, tc.acceptLanguage, tc.body))
+		})
+	}
+}
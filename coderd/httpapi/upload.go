@@ -0,0 +1,277 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// UploadLimits restrict the bodies accepted by ReadMultipart and ReadFile.
+type UploadLimits struct {
+	// MaxPartBytes is the largest a single part or file may be. Zero means
+	// no limit.
+	MaxPartBytes int64
+	// MaxTotalBytes is the largest the whole body may be. Zero means no
+	// limit.
+	MaxTotalBytes int64
+	// ContentTypes are the media types files may have, e.g.
+	// "application/x-tar". Multipart fields without a file name may have any
+	// type. Empty accepts any type.
+	ContentTypes []string
+}
+
+// UploadPart describes a file being uploaded.
+type UploadPart struct {
+	// FormName is the name of the multipart form field, or empty for files
+	// read by ReadFile.
+	FormName string
+	FileName string
+	// ContentType is the media type the client declared, or the sniffed one if
+	// it declared none.
+	ContentType string
+}
+
+// ReadMultipart streams each part of a multipart/form-data body to the writer
+// open returns for it, without buffering whole parts in memory. Parts open
+// returns a nil writer for are skipped. Before a part is written, its content
+// type is checked against limits.ContentTypes and against the type sniffed from
+// its first bytes, so e.g. a zip can't be uploaded as a tar.
+//
+// Violations of the limits are written as a 413 or 415 with a validation for
+// the offending field. Errors returned by open or the writers are written with
+// WriteErrorCtx, so they may be APIErrors. Writers may have received part
+// of a file that's rejected, so they shouldn't commit it until true is
+// returned.
+func ReadMultipart(rw http.ResponseWriter, r *http.Request, limits UploadLimits, open func(part UploadPart) (io.Writer, error)) bool {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	if limits.MaxTotalBytes > 0 {
+		r.Body = http.MaxBytesReader(rw, r.Body, limits.MaxTotalBytes)
+	}
+	defer withBodyProgress(ctx, r)()
+	reader, err := r.MultipartReader()
+	if err != nil {
+		Write(ctx, rw, http.StatusUnsupportedMediaType, codersdk.Response{
+			Message: "Request body must be multipart/form-data.",
+			Detail:  err.Error(),
+		})
+		return false
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return true
+		}
+		if err != nil {
+			if writeBodyTooLarge(ctx, rw, err) {
+				return false
+			}
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid multipart body.",
+				Detail:  err.Error(),
+			})
+			return false
+		}
+
+		upload := UploadPart{
+			FormName:    part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+		}
+		if !readUploadPart(ctx, rw, r, part, upload, limits, open) {
+			_ = part.Close()
+			return false
+		}
+		_ = part.Close()
+	}
+}
+
+// ReadFile is like ReadMultipart for a raw binary body, e.g. a tar archive
+// with a Content-Type of "application/x-tar", which is streamed to w. The file
+// name is taken from the Content-Disposition header if there is one.
+func ReadFile(rw http.ResponseWriter, r *http.Request, limits UploadLimits, w io.Writer) (UploadPart, bool) {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	if limits.MaxTotalBytes > 0 {
+		r.Body = http.MaxBytesReader(rw, r.Body, limits.MaxTotalBytes)
+	}
+	defer withBodyProgress(ctx, r)()
+	upload := UploadPart{ContentType: r.Header.Get("Content-Type")}
+	if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+		upload.FileName = params["filename"]
+	}
+
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+	}
+	ok := readUploadPart(ctx, rw, r, body, upload, limits, func(part UploadPart) (io.Writer, error) {
+		upload = part
+		return w, nil
+	})
+	return upload, ok
+}
+
+// uploadWriteError wraps errors of writers passed to readUploadPart, which
+// are the caller's rather than the client's.
+type uploadWriteError struct {
+	err error
+}
+
+func (e uploadWriteError) Error() string { return e.err.Error() }
+
+func (e uploadWriteError) Unwrap() error { return e.err }
+
+type uploadWriter struct {
+	w io.Writer
+}
+
+func (u uploadWriter) Write(p []byte) (int, error) {
+	n, err := u.w.Write(p)
+	if err != nil {
+		return n, uploadWriteError{err: err}
+	}
+	return n, nil
+}
+
+// readUploadPart checks the content type of the file in body and copies it to
+// the writer open returns for it.
+func readUploadPart(ctx context.Context, rw http.ResponseWriter, r *http.Request, body io.Reader, upload UploadPart, limits UploadLimits, open func(part UploadPart) (io.Writer, error)) bool {
+	// Violations of raw bodies are reported for the header or the body.
+	typeField, sizeField := upload.FormName, upload.FormName
+	if upload.FormName == "" {
+		typeField, sizeField = "Content-Type", "body"
+	}
+
+	// Sniffing looks at most at the first 512 bytes.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return writeUploadReadError(ctx, rw, err)
+	}
+	head = head[:n]
+	sniffed := sniffContentType(head)
+
+	declared, _, err := mime.ParseMediaType(upload.ContentType)
+	if err != nil {
+		declared = sniffed
+		upload.ContentType = sniffed
+	}
+	if !genericContentType(sniffed) && !sameContentType(declared, sniffed) {
+		writeUploadViolation(ctx, rw, http.StatusUnsupportedMediaType, "Uploaded file content doesn't match its type.", codersdk.ValidationError{
+			Field:  typeField,
+			Detail: fmt.Sprintf("declared as %q, but the content is %q", declared, sniffed),
+			Code:   "content_type_mismatch",
+		})
+		return false
+	}
+	// Form fields that aren't files are exempt from the allowed types.
+	isFile := upload.FileName != "" || upload.FormName == ""
+	if isFile && len(limits.ContentTypes) > 0 && !slices.ContainsFunc(limits.ContentTypes, func(allowed string) bool {
+		return sameContentType(declared, allowed)
+	}) {
+		writeUploadViolation(ctx, rw, http.StatusUnsupportedMediaType, "Unsupported upload content type.", codersdk.ValidationError{
+			Field:  typeField,
+			Detail: fmt.Sprintf("%q is not one of %q", declared, limits.ContentTypes),
+			Code:   "unsupported_content_type",
+		})
+		return false
+	}
+
+	w, err := open(upload)
+	if err != nil {
+		WriteErrorCtx(ctx, rw, err)
+		return false
+	}
+	if w == nil {
+		w = io.Discard
+	}
+
+	var src io.Reader = io.MultiReader(bytes.NewReader(head), body)
+	if limits.MaxPartBytes > 0 {
+		// Reading a byte past the limit tells a file at the limit apart from
+		// a larger one.
+		src = io.LimitReader(src, limits.MaxPartBytes+1)
+	}
+	written, err := io.Copy(uploadWriter{w: w}, src)
+	if err != nil {
+		var writeErr uploadWriteError
+		if errors.As(err, &writeErr) {
+			WriteErrorCtx(ctx, rw, writeErr.err)
+			return false
+		}
+		return writeUploadReadError(ctx, rw, err)
+	}
+	if limits.MaxPartBytes > 0 && written > limits.MaxPartBytes {
+		writeUploadViolation(ctx, rw, http.StatusRequestEntityTooLarge, "Uploaded file is too large.", codersdk.ValidationError{
+			Field:  sizeField,
+			Detail: fmt.Sprintf("must be at most %d bytes", limits.MaxPartBytes),
+			Code:   "too_large",
+		})
+		return false
+	}
+	return true
+}
+
+func writeUploadReadError(ctx context.Context, rw http.ResponseWriter, err error) bool {
+	if writeBodyTooLarge(ctx, rw, err) {
+		return false
+	}
+	Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+		Message: "Failed to read uploaded file.",
+		Detail:  err.Error(),
+	})
+	return false
+}
+
+func writeUploadViolation(ctx context.Context, rw http.ResponseWriter, status int, message string, violation codersdk.ValidationError) {
+	Write(ctx, rw, status, codersdk.Response{
+		Message:     message,
+		Validations: []codersdk.ValidationError{violation},
+	})
+}
+
+// sniffContentType is http.DetectContentType without parameters, which also
+// detects tar archives.
+func sniffContentType(head []byte) string {
+	// The magic of POSIX and GNU tar headers is at offset 257.
+	if len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar")) {
+		return "application/x-tar"
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return mediaType
+}
+
+// genericContentType returns whether a sniffed type only says the content is
+// binary or text, which any declared type may be.
+func genericContentType(mediaType string) bool {
+	return mediaType == "application/octet-stream" || mediaType == "text/plain"
+}
+
+// contentTypeAliases map media types to the name they're sniffed as.
+var contentTypeAliases = map[string]string{
+	"application/gzip":             "application/x-gzip",
+	"application/x-zip-compressed": "application/zip",
+	"application/tar":              "application/x-tar",
+}
+
+func sameContentType(a, b string) bool {
+	if alias, ok := contentTypeAliases[a]; ok {
+		a = alias
+	}
+	if alias, ok := contentTypeAliases[b]; ok {
+		b = alias
+	}
+	return a == b
+}
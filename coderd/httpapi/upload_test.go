@@ -0,0 +1,308 @@
+package httpapi_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func tarArchive(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0o644, Size: int64(size)}))
+	_, err := tw.Write(bytes.Repeat([]byte("a"), size))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("main.tf")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("resource {}"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+type uploadFile struct {
+	field       string
+	fileName    string
+	contentType string
+	data        []byte
+}
+
+func multipartRequest(t *testing.T, files ...uploadFile) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, f := range files {
+		h := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + f.field + `"`
+		if f.fileName != "" {
+			disposition += `; filename="` + f.fileName + `"`
+		}
+		h.Set("Content-Disposition", disposition)
+		if f.contentType != "" {
+			h.Set("Content-Type", f.contentType)
+		}
+		w, err := mw.CreatePart(h)
+		require.NoError(t, err)
+		_, err = w.Write(f.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest("POST", "/files", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func uploadResponse(t *testing.T, rw *httptest.ResponseRecorder) codersdk.Response {
+	t.Helper()
+	var resp codersdk.Response
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+	return resp
+}
+
+func TestReadMultipart(t *testing.T) {
+	t.Parallel()
+
+	limits := httpapi.UploadLimits{
+		MaxPartBytes:  4 << 10,
+		MaxTotalBytes: 16 << 10,
+		ContentTypes:  []string{"application/x-tar", "application/zip"},
+	}
+	archive := tarArchive(t, 100)
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		var (
+			parts []httpapi.UploadPart
+			files = map[string]*bytes.Buffer{}
+		)
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t,
+			uploadFile{field: "name", data: []byte("my-template")},
+			uploadFile{field: "archive", fileName: "template.tar", contentType: "application/x-tar", data: archive},
+			uploadFile{field: "zipped", fileName: "template.zip", contentType: "application/x-zip-compressed", data: zipArchive(t)},
+		)
+		ok := httpapi.ReadMultipart(rw, r, limits, func(part httpapi.UploadPart) (io.Writer, error) {
+			parts = append(parts, part)
+			files[part.FormName] = &bytes.Buffer{}
+			return files[part.FormName], nil
+		})
+		require.True(t, ok, rw.Body.String())
+		require.Len(t, parts, 3)
+		require.Equal(t, httpapi.UploadPart{FormName: "name", ContentType: "text/plain"}, parts[0])
+		require.Equal(t, httpapi.UploadPart{FormName: "archive", FileName: "template.tar", ContentType: "application/x-tar"}, parts[1])
+		require.Equal(t, "my-template", files["name"].String())
+		require.Equal(t, archive, files["archive"].Bytes())
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		t.Parallel()
+		var counts []int64
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t,
+			uploadFile{field: "archive", fileName: "template.tar", contentType: "application/x-tar", data: archive},
+		)
+		r = r.WithContext(httpapi.WithReadProgress(r.Context(), 256, func(bytesRead int64) {
+			counts = append(counts, bytesRead)
+		}))
+		// Deliver the body a byte at a time, as a slow upload would.
+		r.Body = io.NopCloser(iotest.OneByteReader(r.Body))
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return io.Discard, nil
+		})
+		require.True(t, ok, rw.Body.String())
+
+		require.Greater(t, len(counts), 1)
+		for i := 1; i < len(counts); i++ {
+			require.Greater(t, counts[i], counts[i-1])
+		}
+		require.Greater(t, counts[len(counts)-1], int64(len(archive)))
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t, uploadFile{field: "archive", fileName: "template.tar", data: archive})
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return nil, nil
+		})
+		require.True(t, ok)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t, uploadFile{field: "archive", fileName: "template.tar", contentType: "application/x-tar", data: zipArchive(t)})
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			t.Fatal("mismatched parts must not be opened")
+			return nil, nil
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		resp := uploadResponse(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "archive", resp.Validations[0].Field)
+		require.Equal(t, "content_type_mismatch", resp.Validations[0].Code)
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t, uploadFile{field: "archive", fileName: "notes.txt", contentType: "text/plain", data: []byte("hi")})
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return io.Discard, nil
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		resp := uploadResponse(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "unsupported_content_type", resp.Validations[0].Code)
+	})
+
+	t.Run("PartTooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t, uploadFile{field: "archive", fileName: "template.tar", data: tarArchive(t, 8<<10)})
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return io.Discard, nil
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		resp := uploadResponse(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "archive", resp.Validations[0].Field)
+		require.Equal(t, "too_large", resp.Validations[0].Code)
+	})
+
+	t.Run("TotalTooLarge", func(t *testing.T) {
+		t.Parallel()
+		var files []uploadFile
+		for i := 0; i < 6; i++ {
+			files = append(files, uploadFile{field: "archive", fileName: "template.tar", data: tarArchive(t, 3<<10)})
+		}
+		rw := httptest.NewRecorder()
+		ok := httpapi.ReadMultipart(rw, multipartRequest(t, files...), limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return io.Discard, nil
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+
+	t.Run("OpenError", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := multipartRequest(t, uploadFile{field: "archive", fileName: "template.tar", data: archive})
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return nil, httpapi.NewAPIError(http.StatusConflict, httpapi.ErrorCodeConflict, "File already exists.")
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusConflict, rw.Code)
+	})
+
+	t.Run("NotMultipart", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/files", strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		ok := httpapi.ReadMultipart(rw, r, limits, func(httpapi.UploadPart) (io.Writer, error) {
+			return io.Discard, nil
+		})
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+}
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+
+	limits := httpapi.UploadLimits{
+		MaxTotalBytes: 4 << 10,
+		ContentTypes:  []string{"application/x-tar"},
+	}
+	read := func(t *testing.T, contentType string, data []byte) (*httptest.ResponseRecorder, httpapi.UploadPart, []byte, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/files", bytes.NewReader(data))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		r.Header.Set("Content-Disposition", `attachment; filename="template.tar"`)
+		var buf bytes.Buffer
+		part, ok := httpapi.ReadFile(rw, r, limits, &buf)
+		return rw, part, buf.Bytes(), ok
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		archive := tarArchive(t, 100)
+		_, part, data, ok := read(t, "application/x-tar", archive)
+		require.True(t, ok)
+		require.Equal(t, httpapi.UploadPart{FileName: "template.tar", ContentType: "application/x-tar"}, part)
+		require.Equal(t, archive, data)
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		t.Parallel()
+		archive := tarArchive(t, 1000)
+		var counts []int64
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/files", iotest.OneByteReader(bytes.NewReader(archive)))
+		r.Header.Set("Content-Type", "application/x-tar")
+		r = r.WithContext(httpapi.WithReadProgress(r.Context(), 512, func(bytesRead int64) {
+			counts = append(counts, bytesRead)
+		}))
+		_, ok := httpapi.ReadFile(rw, r, limits, io.Discard)
+		require.True(t, ok, rw.Body.String())
+
+		require.Greater(t, len(counts), 1)
+		for i := 1; i < len(counts); i++ {
+			require.Greater(t, counts[i], counts[i-1])
+		}
+		require.Equal(t, int64(len(archive)), counts[len(counts)-1])
+	})
+
+	t.Run("Sniffed", func(t *testing.T) {
+		t.Parallel()
+		_, part, _, ok := read(t, "", tarArchive(t, 100))
+		require.True(t, ok)
+		require.Equal(t, "application/x-tar", part.ContentType)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		t.Parallel()
+		rw, _, _, ok := read(t, "application/x-tar", zipArchive(t))
+		require.False(t, ok)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		resp := uploadResponse(t, rw)
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "Content-Type", resp.Validations[0].Field)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw, _, _, ok := read(t, "application/x-tar", tarArchive(t, 8<<10))
+		require.False(t, ok)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+}
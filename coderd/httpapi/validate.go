@@ -0,0 +1,1234 @@
+package httpapi
+
+import (
+	"fmt"
+	"go/token"
+	"math"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/coder/coder/v2/clock"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// validation is a custom field-level validation tag.
+type validation struct {
+	fn validator.Func
+	// detail optionally explains a failure in more depth than the tag alone.
+	detail func(fe validator.FieldError) string
+	// sensitive omits the rejected value from the error detail.
+	sensitive bool
+	// code is reported as the error code instead of the tag, for tags whose
+	// failures share a code or have a more descriptive one.
+	code string
+}
+
+// validations are registered on Validate during init.
+var validations = map[string]validation{
+	"runemax": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && utf8.RuneCountInString(fl.Field().String()) <= paramInt(fl)
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		},
+	},
+	"runemin": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && utf8.RuneCountInString(fl.Field().String()) >= paramInt(fl)
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		},
+	},
+	"fqdn": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && FQDNValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(FQDNValid(fmt.Sprint(fe.Value())))
+		},
+	},
+	"baredomain": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && BareDomainValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(BareDomainValid(fmt.Sprint(fe.Value())))
+		},
+	},
+	"email_strict": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && EmailValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(EmailValid(fmt.Sprint(fe.Value())))
+		},
+		code: "email",
+	},
+	"quantity": {
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			_, err := ParseQuantity(fl.Field().String())
+			return err == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			_, err := ParseQuantity(fmt.Sprint(fe.Value()))
+			return errorDetail(err)
+		},
+		code: "quantity",
+	},
+	"quantitymin": {
+		fn: func(fl validator.FieldLevel) bool {
+			return quantityProblem(fl.Field(), fl.Param(), false) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			return quantityProblem(reflect.ValueOf(fe.Value()), fe.Param(), false)
+		},
+		code: "quantity",
+	},
+	"quantitymax": {
+		fn: func(fl validator.FieldLevel) bool {
+			return quantityProblem(fl.Field(), fl.Param(), true) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			return quantityProblem(reflect.ValueOf(fe.Value()), fe.Param(), true)
+		},
+		code: "quantity",
+	},
+	"gitref": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && GitRefValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(GitRefValid(fmt.Sprint(fe.Value())))
+		},
+	},
+	"goident": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && token.IsIdentifier(fl.Field().String())
+		},
+		detail: func(validator.FieldError) string {
+			return "must be a valid identifier: letters, digits and underscores, not starting with a digit or a Go keyword"
+		},
+		code: "identifier",
+	},
+	"future": {
+		fn: func(fl validator.FieldLevel) bool {
+			t, ok := fl.Field().Interface().(time.Time)
+			return ok && t.After(ValidationClock.Now())
+		},
+		detail: func(validator.FieldError) string {
+			return "must be in the future"
+		},
+	},
+	"past": {
+		fn: func(fl validator.FieldLevel) bool {
+			t, ok := fl.Field().Interface().(time.Time)
+			return ok && t.Before(ValidationClock.Now())
+		},
+		detail: func(validator.FieldError) string {
+			return "must be in the past"
+		},
+	},
+	"mapnonempty": {
+		fn: func(fl validator.FieldLevel) bool {
+			m, ok := fl.Field().Interface().(map[string]string)
+			return ok && emptyMapEntry(m) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			m, _ := fe.Value().(map[string]string)
+			return emptyMapEntry(m)
+		},
+	},
+	"iunique": {
+		fn: func(fl validator.FieldLevel) bool {
+			list, ok := fl.Field().Interface().([]string)
+			return ok && caseInsensitiveDuplicate(list) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			list, _ := fe.Value().([]string)
+			return caseInsensitiveDuplicate(list)
+		},
+	},
+	"percent": {
+		fn: func(fl validator.FieldLevel) bool {
+			f, ok := numericValue(fl.Field())
+			return ok && f >= 0 && f <= 100
+		},
+		detail: func(validator.FieldError) string {
+			return "must be between 0 and 100 inclusive"
+		},
+	},
+	"nonnegative": {
+		fn: func(fl validator.FieldLevel) bool {
+			f, ok := numericValue(fl.Field())
+			return ok && f >= 0
+		},
+		detail: func(validator.FieldError) string {
+			return "must not be negative"
+		},
+	},
+	"positive": {
+		fn: func(fl validator.FieldLevel) bool {
+			f, ok := numericValue(fl.Field())
+			return ok && f > 0
+		},
+		detail: func(validator.FieldError) string {
+			return "must be greater than zero"
+		},
+	},
+	"prefix": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && strings.HasPrefix(fl.Field().String(), fl.Param())
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must start with %q", fe.Param())
+		},
+	},
+	"suffix": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && strings.HasSuffix(fl.Field().String(), fl.Param())
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must end with %q", fe.Param())
+		},
+	},
+	"nopii": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && piiDetected(fl.Field().String()) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			return piiDetected(fmt.Sprint(fe.Value()))
+		},
+		sensitive: true,
+		code:      "pii_detected",
+	},
+	// minentropy rejects secrets with an estimated entropy below the param in
+	// bits, such as short or repetitive values.
+	"minentropy": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && shannonEntropy(fl.Field().String()) >= float64(paramInt(fl))
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("estimated entropy is %.1f bits, at least %s are required", shannonEntropy(fmt.Sprint(fe.Value())), fe.Param())
+		},
+		sensitive: true,
+		code:      "weak_secret",
+	},
+	// This overrides the built-in filepath tag, which checks the path against
+	// the local filesystem. The param is a space separated list of options,
+	// since commas separate tags: "relative" or "absolute" to require either,
+	// and "clean" to require the path to already be in its cleaned form.
+	"filepath": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && filePathProblem(fl.Field().String(), fl.Param()) == ""
+		},
+		detail: func(fe validator.FieldError) string {
+			return filePathProblem(fmt.Sprint(fe.Value()), fe.Param())
+		},
+	},
+	// This overrides the built-in ulid tag, which behaves the same, to explain
+	// failures.
+	"ulid": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && ulidPattern.MatchString(fl.Field().String())
+		},
+		detail: func(validator.FieldError) string {
+			return "must be a 26 character ULID using the Crockford base32 alphabet"
+		},
+	},
+	"ksuid": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && ksuidPattern.MatchString(fl.Field().String())
+		},
+		detail: func(validator.FieldError) string {
+			return "must be a 27 character KSUID using the base62 alphabet"
+		},
+	},
+	"sortedasc": {
+		fn: func(fl validator.FieldLevel) bool {
+			return unsortedIndex(fl.Field(), false) < 0
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must be sorted in ascending order, element %d is out of order", unsortedIndex(reflect.ValueOf(fe.Value()), false))
+		},
+		code: "sorted",
+	},
+	"sorteddesc": {
+		fn: func(fl validator.FieldLevel) bool {
+			return unsortedIndex(fl.Field(), true) < 0
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must be sorted in descending order, element %d is out of order", unsortedIndex(reflect.ValueOf(fe.Value()), true))
+		},
+		code: "sorted",
+	},
+	"multipleof": {
+		fn: func(fl validator.FieldLevel) bool {
+			step := paramInt(fl)
+			if step <= 0 {
+				panic(fmt.Sprintf("multipleof step must be positive, got %d on field %s", step, fl.FieldName()))
+			}
+			switch fl.Field().Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return fl.Field().Int()%int64(step) == 0
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				return fl.Field().Uint()%uint64(step) == 0
+			default:
+				return false
+			}
+		},
+		detail: func(fe validator.FieldError) string {
+			return fmt.Sprintf("must be a multiple of %s", fe.Param())
+		},
+	},
+	// This overrides the built-in ascii tag, which behaves the same, to
+	// explain failures.
+	"ascii": {
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			s := fl.Field().String()
+			for i := 0; i < len(s); i++ {
+				if s[i] >= utf8.RuneSelf {
+					return false
+				}
+			}
+			return true
+		},
+		detail: func(validator.FieldError) string {
+			return "must only contain ASCII characters"
+		},
+	},
+	"k8slabelvalue": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && K8sLabelValueValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(K8sLabelValueValid(fmt.Sprint(fe.Value())))
+		},
+	},
+	"k8slabelkey": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && K8sLabelKeyValid(fl.Field().String()) == nil
+		},
+		detail: func(fe validator.FieldError) string {
+			return errorDetail(K8sLabelKeyValid(fmt.Sprint(fe.Value())))
+		},
+	},
+	// requiredvalue is like required, but only checks the field is present so
+	// zero values such as 0, false or "" are allowed. It's only meaningful on
+	// Optional and pointer fields, which the validator never calls this for
+	// when absent or null, failing them instead. On any other field it would
+	// silently pass, so it panics like a bad tag param does.
+	"requiredvalue": {
+		fn: func(fl validator.FieldLevel) bool {
+			parent := fl.Parent()
+			for parent.Kind() == reflect.Ptr {
+				parent = parent.Elem()
+			}
+			if parent.Kind() == reflect.Struct {
+				field, ok := parent.Type().FieldByName(fl.StructFieldName())
+				if ok && !mayBeAbsent(field.Type) {
+					panic(fmt.Sprintf("requiredvalue on field %s of type %s always passes, it must be an Optional or a pointer", fl.StructFieldName(), field.Type))
+				}
+			}
+			return true
+		},
+		detail: func(validator.FieldError) string {
+			return "must be provided, zero values are allowed"
+		},
+	},
+	// trimmed rejects leading or trailing whitespace, which causes lookup
+	// mismatches. Use notblank to also reject empty values.
+	"trimmed": {
+		fn: func(fl validator.FieldLevel) bool {
+			return fl.Field().Kind() == reflect.String && strings.TrimSpace(fl.Field().String()) == fl.Field().String()
+		},
+		detail: func(validator.FieldError) string {
+			return "must not have leading or trailing whitespace"
+		},
+		code: "whitespace",
+	},
+}
+
+var (
+	// ulidPattern matches a ULID in Crockford base32, which excludes I, L, O
+	// and U. The first character encodes the top 3 bits of a 48-bit timestamp,
+	// so it's at most 7.
+	ulidPattern = regexp.MustCompile(`^(?i)[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	// ksuidPattern matches a KSUID in base62.
+	ksuidPattern = regexp.MustCompile(`^[0-9A-Za-z]{27}$`)
+)
+
+// numericValue returns v as a float64 if it's an integer or float.
+func numericValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// unsortedIndex returns the index of the first element of the string or
+// numeric slice v that breaks its order, or -1 if it's sorted. Equal adjacent
+// elements are allowed. Other kinds are never considered sorted.
+func unsortedIndex(v reflect.Value, desc bool) int {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0
+	}
+	for i := 1; i < v.Len(); i++ {
+		var cmp int
+		prev, cur := v.Index(i-1), v.Index(i)
+		if prev.Kind() == reflect.String {
+			cmp = strings.Compare(prev.String(), cur.String())
+		} else {
+			a, ok := numericValue(prev)
+			if !ok {
+				return 0
+			}
+			b, _ := numericValue(cur)
+			switch {
+			case a < b:
+				cmp = -1
+			case a > b:
+				cmp = 1
+			}
+		}
+		if (desc && cmp < 0) || (!desc && cmp > 0) {
+			return i
+		}
+	}
+	return -1
+}
+
+// caseInsensitiveDuplicate describes the first element of list that
+// duplicates an earlier one, ignoring case. An empty string is returned if all
+// elements are unique.
+func caseInsensitiveDuplicate(list []string) string {
+	seen := make(map[string]int, len(list))
+	for i, v := range list {
+		key := strings.ToLower(v)
+		if first, ok := seen[key]; ok {
+			return fmt.Sprintf("%q at index %d duplicates %q at index %d", v, i, list[first], first)
+		}
+		seen[key] = i
+	}
+	return ""
+}
+
+// emptyMapEntry describes the first entry of m, in key order, with a blank key
+// or value. An empty string is returned if there is none.
+func emptyMapEntry(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Sprintf("key %q must not be empty", k)
+		}
+		if strings.TrimSpace(m[k]) == "" {
+			return fmt.Sprintf("value for key %q must not be empty", k)
+		}
+	}
+	return ""
+}
+
+// shannonEntropy estimates the entropy of s in bits as its length in runes
+// times the Shannon entropy of its rune frequencies. A string repeating a
+// shorter unit, like "abcabcabc", only counts the unit plus the repetitions.
+// It doesn't detect dictionary words or sequences, so it's an upper bound on
+// the real strength.
+func shannonEntropy(s string) float64 {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	period := smallestPeriod(runes)
+	repeats := len(runes) / period
+	runes = runes[:period]
+
+	counts := map[rune]int{}
+	for _, r := range runes {
+		counts[r]++
+	}
+	var perRune float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(runes))
+		perRune -= p * math.Log2(p)
+	}
+	return perRune*float64(len(runes)) + math.Log2(float64(repeats))
+}
+
+// smallestPeriod returns the length of the shortest unit that runes is made of
+// whole repetitions of, or len(runes) if there is none.
+func smallestPeriod(runes []rune) int {
+	// failure[i] is the length of the longest proper prefix of runes[:i+1]
+	// which is also its suffix, as in Knuth-Morris-Pratt.
+	failure := make([]int, len(runes))
+	for i := 1; i < len(runes); i++ {
+		k := failure[i-1]
+		for k > 0 && runes[i] != runes[k] {
+			k = failure[k-1]
+		}
+		if runes[i] == runes[k] {
+			k++
+		}
+		failure[i] = k
+	}
+	period := len(runes) - failure[len(runes)-1]
+	if len(runes)%period != 0 {
+		return len(runes)
+	}
+	return period
+}
+
+// quantityProblem describes why field isn't a quantity of at least, or at
+// most if upper is set, the quantity bound. It's empty if there's none.
+func quantityProblem(field reflect.Value, bound string, upper bool) string {
+	limit, err := ParseQuantity(bound)
+	if err != nil {
+		panic(fmt.Sprintf("invalid quantity bound %q: %s", bound, err))
+	}
+	if field.Kind() != reflect.String {
+		return "must be a string"
+	}
+	value, err := ParseQuantity(field.String())
+	switch {
+	case err != nil:
+		return err.Error()
+	case upper && value > limit:
+		return "must be at most " + bound
+	case !upper && value < limit:
+		return "must be at least " + bound
+	}
+	return ""
+}
+
+// PIIPatterns are the secret formats rejected by the "nopii" validation, in
+// addition to card numbers. It should only be modified during init.
+var PIIPatterns = []*regexp.Regexp{
+	// AWS access key IDs.
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// GitHub tokens.
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	// Slack tokens.
+	regexp.MustCompile(`\bxox[abpors]-[A-Za-z0-9-]{10,}\b`),
+	// Stripe secret keys.
+	regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{16,}\b`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+}
+
+// cardNumberPattern matches runs of 13 to 19 digits, optionally grouped with
+// spaces or dashes, which are then Luhn checked.
+var cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// piiDetected describes the first sensitive value found in s. An empty string
+// is returned if there is none. The value itself is never included.
+func piiDetected(s string) string {
+	for _, match := range cardNumberPattern.FindAllString(s, -1) {
+		if luhnValid(strings.NewReplacer(" ", "", "-", "").Replace(match)) {
+			return "must not contain a card number"
+		}
+	}
+	for _, p := range PIIPatterns {
+		if p.MatchString(s) {
+			return "must not contain secrets such as API keys"
+		}
+	}
+	return ""
+}
+
+// luhnValid reports whether the digits pass the Luhn checksum used by card
+// numbers.
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// filePathProblem describes why p doesn't satisfy the "filepath" options. An
+// empty string is returned if it does. Both slash styles are checked, as
+// paths may be used on either platform.
+func filePathProblem(p, options string) string {
+	slashed := strings.ReplaceAll(p, `\`, "/")
+	// Windows drive letters, e.g. "C:", are absolute too.
+	absolute := strings.HasPrefix(slashed, "/") || (len(p) >= 2 && p[1] == ':' && unicode.IsLetter(rune(p[0])))
+	for _, segment := range strings.Split(slashed, "/") {
+		if segment == ".." {
+			return `must not contain ".." segments`
+		}
+	}
+	for _, option := range strings.Fields(options) {
+		switch option {
+		case "relative":
+			if absolute {
+				return "must be a relative path"
+			}
+		case "absolute":
+			if !absolute {
+				return "must be an absolute path"
+			}
+		case "clean":
+			if slashed != path.Clean(slashed) {
+				return "must be a clean path without redundant separators or dot segments"
+			}
+		default:
+			panic(fmt.Sprintf("invalid filepath option %q", option))
+		}
+	}
+	return ""
+}
+
+// ValidationClock is the clock used by time-relative validations such as
+// "future" and "past". It's only overridden in tests.
+var ValidationClock clock.Clock = clock.NewReal()
+
+// errorDetail returns the message of err, or an empty string if it's nil.
+func errorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// fieldComparisons describe the built-in tags comparing two fields of the
+// same struct, for numbers and for times. The tag param is the Go name of the
+// other field.
+var fieldComparisons = map[string][2]string{
+	"eqfield":  {"must be equal to", "must be equal to"},
+	"nefield":  {"must not be equal to", "must not be equal to"},
+	"gtfield":  {"must be greater than", "must be after"},
+	"gtefield": {"must be greater than or equal to", "must not be before"},
+	"ltfield":  {"must be less than", "must be before"},
+	"ltefield": {"must be less than or equal to", "must not be after"},
+}
+
+// validationErrorDetail formats the Detail of a validation error returned to
+// the client. root is the type that was validated, which is used to name the
+// other field of field comparisons. When trans has a translation for the tag,
+// the translated message is the whole detail, and trans may be nil.
+func validationErrorDetail(fe validator.FieldError, root reflect.Type, trans ut.Translator) string {
+	message := validationErrorMessage(fe, root)
+	// httpapi's own descriptions are in the default locale, so they're only
+	// replaced for other locales.
+	if trans != nil && (message == "" || trans.Locale() != defaultLocale) {
+		if translated := fe.Translate(trans); translated != fe.Error() {
+			return translated
+		}
+	}
+
+	if v, ok := validations[fe.Tag()]; ok && v.sensitive {
+		return fmt.Sprintf("Validation failed for tag %q: %s", fe.Tag(), message)
+	}
+	detail := fmt.Sprintf("Validation failed for tag %q with value: \"%v\"", fe.Tag(), fe.Value())
+	if message != "" {
+		detail += ": " + message
+	}
+	return detail
+}
+
+// validationErrorCode returns the code of a failed tag, which is the tag
+// itself unless its registration names another.
+func validationErrorCode(fe validator.FieldError) string {
+	if v, ok := validations[fe.Tag()]; ok && v.code != "" {
+		return v.code
+	}
+	return fe.Tag()
+}
+
+// toValidationError converts fe into the error reported to clients. root is
+// the type that was validated, and trans localizes the detail. Either may be
+// nil.
+func toValidationError(fe validator.FieldError, root reflect.Type, trans ut.Translator) codersdk.ValidationError {
+	return codersdk.ValidationError{
+		Field:  fe.Field(),
+		Detail: validationErrorDetail(fe, root, trans),
+		Code:   validationErrorCode(fe),
+		ID:     validationErrorID(fe, root),
+	}
+}
+
+// validationErrorMessage describes the failure of tags handled by httpapi,
+// returning an empty string for other tags.
+func validationErrorMessage(fe validator.FieldError, root reflect.Type) string {
+	if comparisons, ok := fieldComparisons[fe.Tag()]; ok {
+		comparison := comparisons[0]
+		if _, isTime := fe.Value().(time.Time); isTime {
+			comparison = comparisons[1]
+		}
+		other := fe.Param()
+		if parent := parentStruct(root, fe.StructNamespace()); parent != nil {
+			if field, ok := parent.FieldByName(other); ok {
+				other = jsonFieldName(field)
+			}
+		}
+		return fmt.Sprintf("%s %s %s", fe.Field(), comparison, other)
+	}
+	if detail, ok := structLevelDetails[fe.Tag()]; ok {
+		return detail(fe)
+	}
+	if v, ok := validations[fe.Tag()]; ok && v.detail != nil {
+		return v.detail(fe)
+	}
+	return ""
+}
+
+// validationErrorID returns the errid tag of the field that failed, which is
+// a stable ID for clients to map errors to, like translation keys. An empty
+// string is returned if the field has no errid tag.
+func validationErrorID(fe validator.FieldError, root reflect.Type) string {
+	parent := parentStruct(root, fe.StructNamespace())
+	if parent == nil {
+		return ""
+	}
+	// Strip any index, e.g. "Items[0]".
+	name, _, _ := strings.Cut(fe.StructField(), "[")
+	field, ok := parent.FieldByName(name)
+	if !ok {
+		return ""
+	}
+	return field.Tag.Get("errid")
+}
+
+// parentStruct walks namespace, such as "Request.Schedule.Start", from root
+// and returns the type of the struct holding the last field. nil is returned
+// if the namespace can't be resolved.
+func parentStruct(root reflect.Type, namespace string) reflect.Type {
+	parts := strings.Split(namespace, ".")
+	if len(parts) < 2 || root == nil {
+		return nil
+	}
+	typ := root
+	// The first part is the root type name, and the last is the field itself.
+	for i := 1; ; i++ {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+		if i == len(parts)-1 {
+			return typ
+		}
+		// Strip any index, e.g. "Items[0]".
+		name, _, _ := strings.Cut(parts[i], "[")
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			return nil
+		}
+		typ = field.Type
+	}
+}
+
+// paramInt parses the tag parameter as an integer. Like the built-in
+// validators, an invalid parameter is a programming error and panics.
+func paramInt(fl validator.FieldLevel) int {
+	i, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("invalid integer param %q for tag on field %s", fl.Param(), fl.FieldName()))
+	}
+	return i
+}
+
+// mayBeAbsent returns true for types that can tell an absent JSON field apart
+// from a zero value.
+func mayBeAbsent(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Interface || typ.Implements(optionalFieldType)
+}
+
+// registrationMu serializes every write to Validate and to the registries
+// beside it, validations and structValidations, so registrations may run
+// concurrently with each other. Neither the validator nor the lookups done
+// for failed requests take it, so registering while requests are validated
+// isn't supported and registrations should happen during init.
+var registrationMu sync.Mutex
+
+// registerValidation registers v for tag on the shared validator after init.
+// Like RegisterValidation, it panics if tag is already defined.
+func registerValidation(tag string, v validation) {
+	registrationMu.Lock()
+	defer registrationMu.Unlock()
+
+	if validationTagDefined(tag) {
+		panic(fmt.Sprintf("validation tag %q is already registered", tag))
+	}
+	err := Validate.RegisterValidation(tag, v.fn)
+	if err != nil {
+		panic(err)
+	}
+	validations[tag] = v
+}
+
+// RegisterValidation registers fn for tag on the shared validator, so packages
+// outside httpapi can add domain rules, such as for cron schedules. Failures
+// are reported by Read like those of any other tag. It panics if tag is
+// already defined, whether it's built in, registered by httpapi or registered
+// by another package, so rules can't silently replace one another.
+//
+// Registrations may be called concurrently with each other, but like all
+// registrations, not with validation, so they should happen during init.
+func RegisterValidation(tag string, fn validator.Func) {
+	registerValidation(tag, validation{fn: fn})
+}
+
+// validationTagDefined returns true if the shared validator knows tag. The
+// validator doesn't expose its tags, so it validates a nil value, which parses
+// the tag without running it, and checks whether parsing panicked.
+func validationTagDefined(tag string) (defined bool) {
+	if _, ok := validations[tag]; ok {
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			defined = !strings.Contains(fmt.Sprint(r), "Undefined validation function")
+		}
+	}()
+	_ = Validate.Var(nil, tag)
+	return true
+}
+
+// RegisterAnyOfPatterns registers a validation for tag that passes if a string
+// field matches at least one of patterns. This is useful for fields that
+// accept several formats, like a UUID or a slug.
+//
+// Like RegisterValidation, it panics if tag is already defined. It isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterAnyOfPatterns(tag string, patterns ...*regexp.Regexp) {
+	exprs := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		exprs = append(exprs, p.String())
+	}
+	registerValidation(tag, validation{
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			for _, p := range patterns {
+				if p.MatchString(fl.Field().String()) {
+					return true
+				}
+			}
+			return false
+		},
+		detail: func(validator.FieldError) string {
+			return fmt.Sprintf("must match one of %s", strings.Join(exprs, ", "))
+		},
+	})
+}
+
+// RegisterDenyPattern registers a validation for tag that fails if a string
+// field matches any of patterns, the inverse of RegisterAnyOfPatterns. The
+// patterns aren't included in the error, so a denylist isn't revealed to
+// clients.
+//
+// Like RegisterValidation, it panics if tag is already defined. It isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterDenyPattern(tag string, patterns ...*regexp.Regexp) {
+	registerValidation(tag, validation{
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			for _, p := range patterns {
+				if p.MatchString(fl.Field().String()) {
+					return false
+				}
+			}
+			return true
+		},
+		detail: func(validator.FieldError) string {
+			return "contains disallowed content"
+		},
+	})
+}
+
+// RegisterReserved registers a validation for tag that rejects string fields
+// matching any of names, ignoring case. This is useful for names that would
+// collide with routes, like "me" or "api".
+//
+// Like RegisterValidation, it panics if tag is already defined. It isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterReserved(tag string, names ...string) {
+	reserved := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		reserved[strings.ToLower(name)] = struct{}{}
+	}
+	registerValidation(tag, validation{
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			_, ok := reserved[strings.ToLower(fl.Field().String())]
+			return !ok
+		},
+		detail: func(validator.FieldError) string {
+			return "is a reserved name"
+		},
+		code: "reserved",
+	})
+}
+
+// RegisterURLSchemes registers a validation for tag that requires a string
+// field to be an absolute URL using one of schemes, compared case-insensitively.
+// Generic URL validation accepts schemes like "file" and "javascript", which
+// are dangerous for fields such as webhook or repository URLs.
+//
+// Like RegisterValidation, it panics if tag is already defined. It isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterURLSchemes(tag string, schemes ...string) {
+	allowed := make(map[string]struct{}, len(schemes))
+	for _, scheme := range schemes {
+		allowed[strings.ToLower(scheme)] = struct{}{}
+	}
+	registerValidation(tag, validation{
+		fn: func(fl validator.FieldLevel) bool {
+			if fl.Field().Kind() != reflect.String {
+				return false
+			}
+			u, err := url.Parse(fl.Field().String())
+			if err != nil || u.Host == "" {
+				return false
+			}
+			_, ok := allowed[strings.ToLower(u.Scheme)]
+			return ok
+		},
+		detail: func(validator.FieldError) string {
+			return fmt.Sprintf("must be a URL with one of the schemes %s", strings.Join(schemes, ", "))
+		},
+		code: "url_scheme",
+	})
+}
+
+// RegisterValidationCtx registers a context-aware validation for tag on the
+// shared validator. The context is the one passed to Read, or the request
+// context with ReadCtx, so validations doing I/O can respect cancellation.
+//
+// Like RegisterValidation, it panics if tag is already defined. It isn't safe
+// to call concurrently with validation and should happen during init.
+func RegisterValidationCtx(tag string, fn validator.FuncCtx) {
+	registrationMu.Lock()
+	defer registrationMu.Unlock()
+
+	if validationTagDefined(tag) {
+		panic(fmt.Sprintf("validation tag %q is already registered", tag))
+	}
+	err := Validate.RegisterValidationCtx(tag, fn)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// structValidations holds every struct-level validation registered for a
+// type. The validator only keeps a single StructLevelFunc per type, so a
+// dispatcher is registered that runs all of them in order.
+var structValidations = map[reflect.Type][]validator.StructLevelFunc{}
+
+// registerStructValidation adds fn to the set of struct-level validations run
+// for the type of structType. Registration is not safe to run concurrently
+// with validation and should happen during init.
+func registerStructValidation(fn validator.StructLevelFunc, structType any) {
+	registrationMu.Lock()
+	defer registrationMu.Unlock()
+
+	typ := reflect.TypeOf(structType)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		panic("struct validations can only be registered for structs, got " + typ.String())
+	}
+
+	structValidations[typ] = append(structValidations[typ], fn)
+	fns := structValidations[typ]
+	Validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		for _, fn := range fns {
+			fn(sl)
+		}
+	}, reflect.New(typ).Elem().Interface())
+}
+
+// structField returns the struct field with the provided Go name along with
+// its JSON name. It panics if the field does not exist, as this is always a
+// programming error in the registration.
+func structField(typ reflect.Type, name string) (reflect.StructField, string) {
+	field, ok := typ.FieldByName(name)
+	if !ok {
+		panic("struct " + typ.String() + " has no field " + name)
+	}
+	return field, jsonFieldName(field)
+}
+
+// jsonFieldName returns the name of field in JSON, which is how fields are
+// reported in validation errors. Like the validator's tag name func, it falls
+// back to the query and form tags for structs bound by ReadQuery or ReadForm.
+func jsonFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			break
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// RegisterStructValidation registers a struct-level validation for each of
+// types, for rules spanning several fields. Errors reported by fn are
+// returned by Read like any other validation error. Fields reported by their
+// Go struct field name are translated to their JSON name.
+//
+//	httpapi.RegisterStructValidation(func(sl validator.StructLevel) {
+//		req := sl.Current().Interface().(CreateScheduleRequest)
+//		if req.End.Before(req.Start) {
+//			sl.ReportError(req.End, "End", "End", "after_start", "")
+//		}
+//	}, CreateScheduleRequest{})
+//
+// Like all registrations, it isn't safe to call concurrently with validation
+// and should happen during init.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	wrapped := func(sl validator.StructLevel) {
+		fn(jsonStructLevel{StructLevel: sl})
+	}
+	for _, typ := range types {
+		registerStructValidation(wrapped, typ)
+	}
+}
+
+// jsonStructLevel reports errors on fields of the current struct under their
+// JSON names.
+type jsonStructLevel struct {
+	validator.StructLevel
+}
+
+func (sl jsonStructLevel) ReportError(field interface{}, fieldName, structFieldName, tag, param string) {
+	current := sl.Current()
+	if current.Kind() == reflect.Struct {
+		if structFieldName == "" {
+			structFieldName = fieldName
+		}
+		if f, ok := current.Type().FieldByName(structFieldName); ok {
+			fieldName = jsonFieldName(f)
+		}
+	}
+	sl.StructLevel.ReportError(field, fieldName, structFieldName, tag, param)
+}
+
+// RegisterGroupRequired registers a struct-level validation on structType
+// which requires that either none or all of the named fields are set. Fields
+// are referenced by their Go names. Every unset member of a partially set
+// group is reported with the "group_required" tag.
+//
+// e.g. an address where city, state and zip must be provided together:
+//
+//	httpapi.RegisterGroupRequired(Address{}, "City", "State", "Zip")
+func RegisterGroupRequired(structType any, group ...string) {
+	typ := reflect.TypeOf(structType)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for _, name := range group {
+		// Fail fast on typos instead of at validation time.
+		_, _ = structField(typ, name)
+	}
+
+	registerStructValidation(func(sl validator.StructLevel) {
+		current := sl.Current()
+		var missing []string
+		for _, name := range group {
+			if current.FieldByName(name).IsZero() {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) == 0 || len(missing) == len(group) {
+			return
+		}
+		for _, name := range missing {
+			_, jsonName := structField(current.Type(), name)
+			sl.ReportError(current.FieldByName(name).Interface(), jsonName, name, "group_required", strings.Join(group, " "))
+		}
+	}, structType)
+}
+
+// structLevelDetails explains the tags reported by the struct-level
+// validations registered in this package.
+var structLevelDetails = map[string]func(fe validator.FieldError) string{
+	"at_least": func(fe validator.FieldError) string {
+		n, fields, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("at least %s of %s must be set", n, strings.ReplaceAll(fields, " ", ", "))
+	},
+	"span_too_large": func(fe validator.FieldError) string {
+		maxSpan, start, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("must not be more than %s after %s", maxSpan, start)
+	},
+	"sum_exceeded": func(fe validator.FieldError) string {
+		maxSum, weight, _ := strings.Cut(fe.Param(), " ")
+		return fmt.Sprintf("total %s must be at most %s", weight, maxSum)
+	},
+}
+
+// RegisterAtLeast registers a struct-level validation on structType which
+// requires that at least n of the named fields are set. Fields are referenced
+// by their Go names. Failures are reported on the first field with the
+// "at_least" tag and the number of fields that were set as the value.
+//
+// e.g. notification settings which need at least one contact method:
+//
+//	httpapi.RegisterAtLeast(NotificationSettings{}, 1, "Email", "Slack", "Webhook")
+func RegisterAtLeast(structType any, n int, fields ...string) {
+	typ := reflect.TypeOf(structType)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if n < 1 || n > len(fields) {
+		panic(fmt.Sprintf("at least %d of %d fields can never be satisfied", n, len(fields)))
+	}
+	jsonNames := make([]string, 0, len(fields))
+	for _, name := range fields {
+		// Fail fast on typos instead of at validation time.
+		_, jsonName := structField(typ, name)
+		jsonNames = append(jsonNames, jsonName)
+	}
+	param := fmt.Sprintf("%d %s", n, strings.Join(jsonNames, " "))
+
+	registerStructValidation(func(sl validator.StructLevel) {
+		current := sl.Current()
+		var set int
+		for _, name := range fields {
+			if !current.FieldByName(name).IsZero() {
+				set++
+			}
+		}
+		if set < n {
+			sl.ReportError(set, jsonNames[0], fields[0], "at_least", param)
+		}
+	}, structType)
+}
+
+// RegisterMaxSpan registers a struct-level validation on structType which
+// requires that the time.Time fields startField and endField, referenced by
+// their Go names, are at most maxSpan apart. The range isn't checked if either
+// is zero. Failures are reported on endField with the "span_too_large" tag
+// and the actual span as the value.
+//
+// e.g. a report which may cover at most 90 days:
+//
+//	httpapi.RegisterMaxSpan(ReportRequest{}, "Start", "End", 90*24*time.Hour)
+func RegisterMaxSpan(structType any, startField, endField string, maxSpan time.Duration) {
+	typ := reflect.TypeOf(structType)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	timeType := reflect.TypeOf(time.Time{})
+	var jsonNames [2]string
+	for i, name := range []string{startField, endField} {
+		var field reflect.StructField
+		field, jsonNames[i] = structField(typ, name)
+		if field.Type != timeType {
+			panic("field " + name + " of struct " + typ.String() + " must be a time.Time")
+		}
+	}
+	param := maxSpan.String() + " " + jsonNames[0]
+
+	registerStructValidation(func(sl validator.StructLevel) {
+		current := sl.Current()
+		start := current.FieldByName(startField).Interface().(time.Time)
+		end := current.FieldByName(endField).Interface().(time.Time)
+		if start.IsZero() || end.IsZero() {
+			return
+		}
+		if span := end.Sub(start); span > maxSpan {
+			sl.ReportError(span, jsonNames[1], endField, "span_too_large", param)
+		}
+	}, structType)
+}
+
+// RegisterSliceSum registers a struct-level validation on structType which
+// requires that the numeric weightField of the elements of sliceField sum to
+// at most maxSum. Fields are referenced by their Go names, and elements may
+// be structs or pointers to them, with nil pointers skipped. Failures are
+// reported on sliceField with the "sum_exceeded" tag and the sum as the
+// value.
+//
+// e.g. a batch of builds which may request at most 16 CPUs in total:
+//
+//	httpapi.RegisterSliceSum(BatchRequest{}, "Builds", "CPU", 16)
+func RegisterSliceSum(structType any, sliceField, weightField string, maxSum float64) {
+	typ := reflect.TypeOf(structType)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	slice, sliceName := structField(typ, sliceField)
+	if slice.Type.Kind() != reflect.Slice && slice.Type.Kind() != reflect.Array {
+		panic("field " + sliceField + " of struct " + typ.String() + " must be a slice")
+	}
+	elem := slice.Type.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		panic("elements of field " + sliceField + " of struct " + typ.String() + " must be structs")
+	}
+	weight, weightName := structField(elem, weightField)
+	if _, ok := numericValue(reflect.New(weight.Type).Elem()); !ok {
+		panic("field " + weightField + " of struct " + elem.String() + " must be numeric")
+	}
+	param := strconv.FormatFloat(maxSum, 'f', -1, 64) + " " + weightName
+
+	registerStructValidation(func(sl validator.StructLevel) {
+		items := sl.Current().FieldByName(sliceField)
+		var sum float64
+		for i := 0; i < items.Len(); i++ {
+			item := items.Index(i)
+			for item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					break
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct {
+				continue
+			}
+			value, _ := numericValue(item.FieldByName(weightField))
+			sum += value
+		}
+		if sum > maxSum {
+			sl.ReportError(sum, sliceName, sliceField, "sum_exceeded", param)
+		}
+	}, structType)
+}
@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ReadVersioned is like Read, but accepts older versions of the request
+// schema. The body must contain an integer "version" field. upgraders[v]
+// converts a version v body into a version v+1 body, and the current version
+// is one past the highest upgrader. A body is passed through each upgrader
+// from its version up to the current one before being decoded and validated
+// into target, which always has the current schema.
+//
+// Versions below 1, above the current one, or missing an upgrader are
+// rejected with a 400. Bodies are read like Read reads them, and ones in
+// other accepted codecs are converted to JSON for the upgraders.
+func ReadVersioned(rw http.ResponseWriter, r *http.Request, target interface{}, upgraders map[int]func(json.RawMessage) (json.RawMessage, error)) bool {
+	ctx, span := tracing.StartSpan(r.Context())
+	defer span.End()
+
+	var (
+		raw json.RawMessage
+		err error
+	)
+	if _, ok := requestCodec(r); ok {
+		var body interface{}
+		if !readBody(ctx, rw, r, &body) {
+			return false
+		}
+		raw, err = json.Marshal(body)
+		if err != nil {
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Request body can't be represented as JSON.",
+				Detail:  err.Error(),
+			})
+			return false
+		}
+	} else if !readBody(ctx, rw, r, &raw) {
+		return false
+	}
+
+	var header struct {
+		Version *int `json:"version"`
+	}
+	err = json.Unmarshal(raw, &header)
+	if err != nil || header.Version == nil {
+		detail, code := `"version" must be an integer`, string(ErrorCodeInvalidType)
+		if err == nil {
+			detail, code = `"version" is required`, "required"
+		}
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid request schema version.",
+			Validations: []codersdk.ValidationError{
+				{Field: "version", Detail: detail, Code: code},
+			},
+		})
+		return false
+	}
+
+	current := 1
+	for v := range upgraders {
+		if v+1 > current {
+			current = v + 1
+		}
+	}
+	version := *header.Version
+	if version < 1 || version > current {
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Unsupported request schema version.",
+			Detail:  fmt.Sprintf("Version %d is not supported, the current version is %d.", version, current),
+		})
+		return false
+	}
+
+	for v := version; v < current; v++ {
+		upgrade, ok := upgraders[v]
+		if !ok {
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Unsupported request schema version.",
+				Detail:  fmt.Sprintf("Version %d can no longer be upgraded, the current version is %d.", v, current),
+			})
+			return false
+		}
+		raw, err = upgrade(raw)
+		if err != nil {
+			Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Failed to upgrade request from version %d.", v),
+				Detail:  err.Error(),
+			})
+			return false
+		}
+	}
+
+	err = json.Unmarshal(raw, target)
+	if err != nil {
+		var validations []codersdk.ValidationError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			validations = append(validations, typeErrorValidation(typeErr, raw))
+			observeDecodeFailures(ctx, reflect.TypeOf(target), validations, ErrorCodeInvalidType)
+		}
+		Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Request body must be valid JSON.",
+			Detail:      err.Error(),
+			Validations: validations,
+		})
+		return false
+	}
+	return validateRequest(ctx, rw, r, target)
+}
@@ -0,0 +1,129 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestReadVersioned(t *testing.T) {
+	t.Parallel()
+
+	// Version 1 had a single "name", version 2 split it up.
+	type requestV2 struct {
+		Version   int    `json:"version" validate:"eq=2"`
+		FirstName string `json:"first_name" validate:"required"`
+		LastName  string `json:"last_name"`
+	}
+	upgraders := map[int]func(json.RawMessage) (json.RawMessage, error){
+		1: func(raw json.RawMessage) (json.RawMessage, error) {
+			var v1 struct {
+				Name string `json:"name"`
+			}
+			err := json.Unmarshal(raw, &v1)
+			if err != nil {
+				return nil, err
+			}
+			first, last, _ := strings.Cut(v1.Name, " ")
+			return json.Marshal(requestV2{Version: 2, FirstName: first, LastName: last})
+		},
+	}
+
+	read := func(t *testing.T, body string) (*httptest.ResponseRecorder, requestV2, bool) {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+		var v requestV2
+		ok := httpapi.ReadVersioned(rw, r, &v, upgraders)
+		return rw, v, ok
+	}
+
+	t.Run("Upgraded", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, `{"version":1,"name":"Ada Lovelace"}`)
+		require.True(t, ok)
+		require.Equal(t, requestV2{Version: 2, FirstName: "Ada", LastName: "Lovelace"}, v)
+	})
+
+	t.Run("Current", func(t *testing.T) {
+		t.Parallel()
+		_, v, ok := read(t, `{"version":2,"first_name":"Ada"}`)
+		require.True(t, ok)
+		require.Equal(t, "Ada", v.FirstName)
+	})
+
+	t.Run("Validated", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"version":1,"name":""}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("Charset", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"version":2,"first_name":"Ada"}`))
+		r.Header.Set("Content-Type", "application/json; charset=shift_jis")
+		require.False(t, httpapi.ReadVersioned(rw, r, &requestV2{}, upgraders))
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewBufferString(`{"version":2,"first_name":"`+strings.Repeat("a", 64)+`"}`))
+		r.Body = http.MaxBytesReader(rw, r.Body, 32)
+		require.False(t, httpapi.ReadVersioned(rw, r, &requestV2{}, upgraders))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+	})
+
+	t.Run("TypeError", func(t *testing.T) {
+		t.Parallel()
+		rw, _, ok := read(t, `{"version":2,"first_name":7}`)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Len(t, resp.Validations, 1)
+		require.Equal(t, "first_name", resp.Validations[0].Field)
+		require.Equal(t, string(httpapi.ErrorCodeInvalidType), resp.Validations[0].Code)
+	})
+
+	t.Run("Msgpack", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(map[string]interface{}{"version": 1, "name": "Ada Lovelace"})
+		require.NoError(t, err)
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", httpapi.ContentTypeMsgpack)
+		r = r.WithContext(httpapi.WithAcceptedCodecs(r.Context(), httpapi.MsgpackCodec))
+		var v requestV2
+		require.True(t, httpapi.ReadVersioned(rw, r, &v, upgraders))
+		require.Equal(t, requestV2{Version: 2, FirstName: "Ada", LastName: "Lovelace"}, v)
+	})
+
+	for _, body := range []string{
+		`{"version":3,"first_name":"Ada"}`,
+		`{"version":0,"name":"Ada"}`,
+		`{"name":"Ada"}`,
+		`{"version":"1","name":"Ada"}`,
+	} {
+		t.Run("Rejected", func(t *testing.T) {
+			t.Parallel()
+			rw, _, ok := read(t, body)
+			require.False(t, ok)
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			require.Contains(t, resp.Message, "version")
+		})
+	}
+}
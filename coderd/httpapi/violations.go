@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// ToFieldViolations translates the validator.ValidationErrors in err, as
+// returned by Validate.Struct, into google.rpc.BadRequest field violations so
+// gRPC-gateway handlers can share validation with the REST API. Fields are
+// paths of JSON names like "email_addresses[1].email", and descriptions match
+// the details returned by Read. nil is returned if err holds no validation
+// errors.
+func ToFieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		// The namespace is prefixed with the name of the validated type.
+		_, field, ok := strings.Cut(fe.Namespace(), ".")
+		if !ok {
+			field = fe.Field()
+		}
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: validationErrorDetail(fe, nil, nil),
+		})
+	}
+	return violations
+}
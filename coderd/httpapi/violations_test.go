@@ -0,0 +1,36 @@
+package httpapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+func TestToFieldViolations(t *testing.T) {
+	t.Parallel()
+
+	type emailAddress struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	type createContactRequest struct {
+		FullName       string         `json:"full_name" validate:"required"`
+		EmailAddresses []emailAddress `json:"email_addresses" validate:"dive"`
+	}
+
+	err := httpapi.Validate.Struct(createContactRequest{
+		EmailAddresses: []emailAddress{{Email: "a@coder.com"}, {Email: "invalid"}},
+	})
+	violations := httpapi.ToFieldViolations(xerrors.Errorf("validate: %w", err))
+	require.Len(t, violations, 2)
+
+	require.Equal(t, "full_name", violations[0].GetField())
+	require.Contains(t, violations[0].GetDescription(), `"required"`)
+	require.Equal(t, "email_addresses[1].email", violations[1].GetField())
+	require.Contains(t, violations[1].GetDescription(), `"email"`)
+
+	require.Nil(t, httpapi.ToFieldViolations(nil))
+	require.Nil(t, httpapi.ToFieldViolations(xerrors.New("not a validation error")))
+}
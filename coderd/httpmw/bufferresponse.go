@@ -0,0 +1,105 @@
+package httpmw
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// BufferResponse holds back the status and body written by the handler until
+// it returns, as long as the body fits in maxBytes. While buffered, writing a
+// status again discards everything written so far, so a handler that fails
+// part way through a response can still replace it with httpapi.Write.
+// Once the body outgrows maxBytes, or the handler flushes, the response is
+// sent and further writes stream through as usual.
+//
+// It must be used inside middleware that inspect the written status, such as
+// Prometheus, since they get the buffered writer rather than a
+// tracing.StatusWriter.
+func BufferResponse(maxBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			bw := &bufferedResponseWriter{ResponseWriter: rw, maxBytes: maxBytes}
+			next.ServeHTTP(bw, r)
+			if !bw.hijacked {
+				_ = bw.stream()
+			}
+		})
+	}
+}
+
+var (
+	_ http.Flusher  = (*bufferedResponseWriter)(nil)
+	_ http.Hijacker = (*bufferedResponseWriter)(nil)
+)
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	maxBytes  int
+	status    int
+	buf       bytes.Buffer
+	streaming bool
+	hijacked  bool
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.buf.Reset()
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if !w.streaming && w.buf.Len()+len(b) <= w.maxBytes {
+		return w.buf.Write(b)
+	}
+	err := w.stream()
+	if err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// stream sends the buffered status and body, after which writes go straight
+// to the underlying writer.
+func (w *bufferedResponseWriter) stream() error {
+	if w.streaming {
+		return nil
+	}
+	w.streaming = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *bufferedResponseWriter) Flush() {
+	_ = w.stream()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bufferedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, xerrors.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, brw, err
+}
+
+func (w *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,79 @@
+package httpmw_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestBufferResponse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OverrideStatus", func(t *testing.T) {
+		t.Parallel()
+		handler := httpmw.BufferResponse(1024)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+			_, _ = rw.Write([]byte(`{"items":[`))
+			// The handler fails part way through, and replaces the response.
+			httpapi.Write(context.Background(), rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to list items.",
+			})
+		}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "Failed to list items.", resp.Message)
+	})
+
+	t.Run("ImplicitStatus", func(t *testing.T) {
+		t.Parallel()
+		handler := httpmw.BufferResponse(1024)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("hello"))
+		}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "hello", rw.Body.String())
+	})
+
+	t.Run("ExceedsCap", func(t *testing.T) {
+		t.Parallel()
+		chunk := strings.Repeat("a", 16)
+		handler := httpmw.BufferResponse(32)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusAccepted)
+			for i := 0; i < 4; i++ {
+				_, _ = rw.Write([]byte(chunk))
+			}
+			// The response has already been sent, so this can't change it.
+			rw.WriteHeader(http.StatusInternalServerError)
+		}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusAccepted, rw.Code)
+		require.Equal(t, strings.Repeat(chunk, 4), rw.Body.String())
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		t.Parallel()
+		handler := httpmw.BufferResponse(1024)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("event"))
+			rw.(http.Flusher).Flush()
+		}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.True(t, rw.Flushed)
+		require.Equal(t, "event", rw.Body.String())
+	})
+}
@@ -0,0 +1,14 @@
+package httpmw
+
+import "net/http"
+
+// Chain composes middlewares into one that applies them left to right, so the
+// first listed runs first. This avoids hand-nesting, which reads backwards.
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
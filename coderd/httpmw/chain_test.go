@@ -0,0 +1,35 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(rw, r)
+			})
+		}
+	}
+
+	handler := httpmw.Chain(record("first"), record("second"), record("third"))(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, []string{"first", "second", "third", "handler"}, order)
+}
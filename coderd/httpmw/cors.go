@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-chi/cors"
 
+	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/workspaceapps/appurl"
 )
 
@@ -39,6 +40,8 @@ func Cors(allowAll bool, origins ...string) func(next http.Handler) http.Handler
 		// We only need GET for latency requests
 		AllowedMethods: []string{http.MethodOptions, http.MethodGet},
 		AllowedHeaders: []string{"Accept", "Content-Type", "X-LATENCY-CHECK", "X-CSRF-TOKEN"},
+		// Set by httpapi.WriteListWithCount.
+		ExposedHeaders: []string{httpapi.TotalCountHeader},
 		// Do not send any cookies
 		AllowCredentials: false,
 	})
@@ -0,0 +1,29 @@
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecated marks every response from the wrapped handler as deprecated with
+// the Deprecation and Sunset headers (RFC 8594), linking to link for
+// migration details, and adds a Warning header for clients that only surface
+// those. The response body is untouched.
+func Deprecated(sunset time.Time, link string) func(http.Handler) http.Handler {
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	warning := fmt.Sprintf("299 - %q", "This endpoint is deprecated and will be removed after "+sunsetDate+".")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", "true")
+			h.Set("Sunset", sunsetDate)
+			if link != "" {
+				h.Add("Link", fmt.Sprintf("<%s>; rel=%q", link, "sunset"))
+			}
+			h.Add("Warning", warning)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
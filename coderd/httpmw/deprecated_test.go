@@ -0,0 +1,32 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+)
+
+func TestDeprecated(t *testing.T) {
+	t.Parallel()
+
+	sunset := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	handler := httpmw.Deprecated(sunset, "https://coder.com/docs/migrate")(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte("body"))
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/api/v2/legacy", nil))
+
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, "body", rw.Body.String())
+	require.Equal(t, "true", rw.Header().Get("Deprecation"))
+	require.Equal(t, "Fri, 31 Jan 2025 00:00:00 GMT", rw.Header().Get("Sunset"))
+	require.Equal(t, `<https://coder.com/docs/migrate>; rel="sunset"`, rw.Header().Get("Link"))
+	require.Contains(t, rw.Header().Get("Warning"), "Fri, 31 Jan 2025 00:00:00 GMT")
+}
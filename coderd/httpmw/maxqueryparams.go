@@ -0,0 +1,47 @@
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// MaxQueryParams rejects requests with more than n query parameters with a
+// 400. Parameters are counted on the raw query without parsing it, so a
+// crafted query can't cause excessive work before it's rejected.
+func MaxQueryParams(n int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if queryParamsExceed(r.URL.RawQuery, n) {
+				httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+					Message: "too many query parameters",
+					Detail:  fmt.Sprintf("At most %d query parameters are allowed.", n),
+				})
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// queryParamsExceed reports whether rawQuery has more than n parameters,
+// counted like url.ParseQuery, which skips empty segments. It stops as soon
+// as the limit is exceeded.
+func queryParamsExceed(rawQuery string, n int) bool {
+	var count int
+	for rawQuery != "" {
+		var segment string
+		segment, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if segment == "" {
+			continue
+		}
+		count++
+		if count > n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package httpmw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestMaxQueryParams(t *testing.T) {
+	t.Parallel()
+
+	handler := httpmw.MaxQueryParams(3)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("AtLimit", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		// Empty segments aren't counted.
+		r := httptest.NewRequest("GET", "/api/v2/workspaces?a=1&b=2&&c=3&", nil)
+		handler.ServeHTTP(rw, r)
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+
+	t.Run("OverLimit", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/workspaces?"+strings.Repeat("a=1&", 1000), nil)
+		handler.ServeHTTP(rw, r)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "too many query parameters", resp.Message)
+	})
+}
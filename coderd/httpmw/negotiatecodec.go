@@ -0,0 +1,26 @@
+package httpmw
+
+import (
+	"net/http"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+)
+
+// NegotiateCodec lets routes speak codecs besides JSON, so clients such as
+// provisioner daemons can send and ask for msgpack or protobuf. Request bodies
+// in codecs are decoded by httpapi.Read, and responses are encoded in the one
+// the Accept header prefers, which httpapi.Write and streaming handlers find
+// with httpapi.CodecFromContext. Routes should only opt in to codecs their
+// request and response types support.
+func NegotiateCodec(codecs ...httpapi.Codec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Add("Vary", "Accept")
+			ctx := httpapi.WithAcceptedCodecs(r.Context(), codecs...)
+			if codec := httpapi.NegotiateCodec(r, codecs...); codec != httpapi.JSONCodec {
+				ctx = httpapi.WithCodec(ctx, codec)
+			}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package httpmw_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestNegotiateCodec(t *testing.T) {
+	t.Parallel()
+
+	handler := httpmw.NegotiateCodec(httpapi.MsgpackCodec, httpapi.ProtobufCodec)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req codersdk.Response
+		if r.Method == http.MethodPost && !httpapi.Read(r.Context(), rw, r, &req) {
+			return
+		}
+		httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.Response{Message: "hello " + req.Message})
+	}))
+
+	for _, tc := range []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "None", contentType: "application/json; charset=utf-8"},
+		{name: "Any", accept: "*/*", contentType: "application/json; charset=utf-8"},
+		{name: "Msgpack", accept: "application/msgpack", contentType: httpapi.ContentTypeMsgpack},
+		{name: "PreferMsgpack", accept: "application/json;q=0.5, application/msgpack", contentType: httpapi.ContentTypeMsgpack},
+		{name: "PreferJSON", accept: "application/json, application/msgpack;q=0.5", contentType: "application/json; charset=utf-8"},
+		// Responses that aren't protobuf messages fall back to JSON.
+		{name: "Protobuf", accept: "application/x-protobuf", contentType: "application/json; charset=utf-8"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			require.Equal(t, http.StatusOK, rw.Code)
+			require.Equal(t, "Accept", rw.Header().Get("Vary"))
+			require.Equal(t, tc.contentType, rw.Header().Get("Content-Type"))
+			if tc.contentType == httpapi.ContentTypeMsgpack {
+				var resp codersdk.Response
+				err := msgpack.NewDecoder(rw.Body).UseJSONTag(true).Decode(&resp)
+				require.NoError(t, err)
+				require.Equal(t, "hello ", resp.Message)
+			}
+		})
+	}
+
+	t.Run("ReadMsgpack", func(t *testing.T) {
+		t.Parallel()
+		body, err := httpapi.MsgpackCodec.Marshal(codersdk.Response{Message: "world"})
+		require.NoError(t, err)
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", httpapi.ContentTypeMsgpack)
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, r)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "hello world", resp.Message)
+	})
+}
@@ -5,8 +5,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/tracing"
 
@@ -61,7 +59,6 @@ func Prometheus(register prometheus.Registerer) func(http.Handler) http.Handler
 			var (
 				start  = time.Now()
 				method = r.Method
-				rctx   = chi.RouteContext(r.Context())
 			)
 
 			sw, ok := w.(*tracing.StatusWriter)
@@ -89,7 +86,7 @@ func Prometheus(register prometheus.Registerer) func(http.Handler) http.Handler
 
 			next.ServeHTTP(w, r)
 
-			path := rctx.RoutePattern()
+			path := RoutePatternFromContext(r.Context())
 			distOpts = append(distOpts, path)
 			statusStr := strconv.Itoa(sw.Status)
 
@@ -0,0 +1,71 @@
+package httpmw
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// QuotaChecker decides whether the request identified by key is within its
+// quota, e.g. by asking an external per-organization budget service.
+// remaining and reset describe the quota after this request.
+type QuotaChecker interface {
+	Check(ctx context.Context, key string) (allowed bool, remaining int, reset time.Time, err error)
+}
+
+// QuotaKeyByIP keys requests by the IP they came from. Use RealIP first so
+// requests behind a trusted proxy are keyed by the client.
+func QuotaKeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Quota checks every request against checker, keyed by key, or by IP if key
+// is nil. The remaining quota and its reset time are reported in the
+// X-RateLimit-Remaining and X-RateLimit-Reset headers like RateLimit. Denied
+// requests are rejected with a 429 and a Retry-After header. Requests are
+// rejected with a 503 if the checker fails, so an outage can't be used to
+// bypass the quota.
+func Quota(checker QuotaChecker, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = QuotaKeyByIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			allowed, remaining, reset, err := checker.Check(ctx, key(r))
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusServiceUnavailable, codersdk.Response{
+					Message: "Unable to check the request quota.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			rw.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !reset.IsZero() {
+				rw.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			}
+			if !allowed {
+				if !reset.IsZero() {
+					wait := math.Ceil(time.Until(reset).Seconds())
+					rw.Header().Set("Retry-After", strconv.Itoa(int(math.Max(wait, 0))))
+				}
+				httpapi.Write(ctx, rw, http.StatusTooManyRequests, codersdk.Response{
+					Message: "You've exceeded your request quota.",
+				})
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpmw_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+type fakeQuotaChecker struct {
+	allowed   bool
+	remaining int
+	reset     time.Time
+	err       error
+	keys      []string
+}
+
+func (c *fakeQuotaChecker) Check(_ context.Context, key string) (bool, int, time.Time, error) {
+	c.keys = append(c.keys, key)
+	return c.allowed, c.remaining, c.reset, c.err
+}
+
+func TestQuota(t *testing.T) {
+	t.Parallel()
+
+	serve := func(checker *fakeQuotaChecker, key func(r *http.Request) string) *httptest.ResponseRecorder {
+		handler := httpmw.Quota(checker, key)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		}))
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/workspaces", nil)
+		r.RemoteAddr = "10.0.0.1:51234"
+		r.Header.Set("X-Org", "acme")
+		handler.ServeHTTP(rw, r)
+		return rw
+	}
+
+	t.Run("Allowed", func(t *testing.T) {
+		t.Parallel()
+		reset := time.Now().Add(time.Hour)
+		checker := &fakeQuotaChecker{allowed: true, remaining: 9, reset: reset}
+		rw := serve(checker, nil)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, []string{"10.0.0.1"}, checker.keys)
+		require.Equal(t, "9", rw.Header().Get("X-RateLimit-Remaining"))
+		require.Equal(t, strconv.FormatInt(reset.Unix(), 10), rw.Header().Get("X-RateLimit-Reset"))
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		t.Parallel()
+		checker := &fakeQuotaChecker{reset: time.Now().Add(30 * time.Second)}
+		rw := serve(checker, func(r *http.Request) string {
+			return r.Header.Get("X-Org")
+		})
+		require.Equal(t, http.StatusTooManyRequests, rw.Code)
+		require.Equal(t, []string{"acme"}, checker.keys)
+		require.Equal(t, "0", rw.Header().Get("X-RateLimit-Remaining"))
+		retryAfter, err := strconv.Atoi(rw.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		require.InDelta(t, 30, retryAfter, 1)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.NotEmpty(t, resp.Message)
+	})
+
+	t.Run("CheckerError", func(t *testing.T) {
+		t.Parallel()
+		checker := &fakeQuotaChecker{allowed: true, err: xerrors.New("quota service unreachable")}
+		rw := serve(checker, nil)
+		require.Equal(t, http.StatusServiceUnavailable, rw.Code)
+		require.Empty(t, rw.Header().Get("X-RateLimit-Remaining"))
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "quota service unreachable", resp.Detail)
+	})
+}
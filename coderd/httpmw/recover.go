@@ -2,14 +2,20 @@ package httpmw
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"runtime/debug"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
 )
 
+// Recover responds to panics with a 500 in the standard format, instead of
+// the connection being closed without a response. If AttachRequestID ran
+// before the panic, the response includes the request ID so it can be matched
+// with the logged stack trace.
 func Recover(log slog.Logger) func(h http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -22,10 +28,14 @@ func Recover(log slog.Logger) func(h http.Handler) http.Handler {
 				//
 				//nolint:errorlint // this is how the stdlib does the check
 				if r != nil && r != http.ErrAbortHandler {
+					// AttachRequestID runs after this middleware, so the ID is
+					// only in the response headers.
+					requestID := w.Header().Get(RequestIDHeader)
 					log.Warn(context.Background(),
 						"panic serving http request (recovered)",
 						slog.F("panic", r),
 						slog.F("stack", string(debug.Stack())),
+						slog.F("request_id", requestID),
 					)
 
 					var hijacked bool
@@ -36,7 +46,13 @@ func Recover(log slog.Logger) func(h http.Handler) http.Handler {
 					// Only try to write errors on
 					// non-hijacked responses.
 					if !hijacked {
-						httpapi.InternalServerError(w, nil)
+						resp := codersdk.Response{
+							Message: "An internal server error occurred.",
+						}
+						if requestID != "" {
+							resp.Detail = fmt.Sprintf("Request ID: %s", requestID)
+						}
+						httpapi.Write(context.Background(), w, http.StatusInternalServerError, resp)
 					}
 				}
 			}()
@@ -1,6 +1,7 @@
 package httpmw_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/v2/coderd/httpmw"
 	"github.com/coder/coder/v2/coderd/tracing"
+	"github.com/coder/coder/v2/codersdk"
 )
 
 func TestRecover(t *testing.T) {
@@ -71,4 +73,22 @@ func TestRecover(t *testing.T) {
 			require.Equal(t, c.Code, w.Status)
 		})
 	}
+
+	t.Run("RequestID", func(t *testing.T) {
+		t.Parallel()
+
+		log := slogtest.Make(t, nil)
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		h := httpmw.Recover(log)(httpmw.AttachRequestID(handler(true, false)))
+		h.ServeHTTP(rw, r)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		requestID := rw.Header().Get(httpmw.RequestIDHeader)
+		require.NotEmpty(t, requestID)
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "An internal server error occurred.", resp.Message)
+		require.Equal(t, "Request ID: "+requestID, resp.Detail)
+	})
 }
@@ -11,6 +11,10 @@ import (
 	"cdr.dev/slog"
 )
 
+// RequestIDHeader is the response header AttachRequestID sets to the ID of
+// the request, so users can include it in bug reports.
+const RequestIDHeader = "X-Coder-Request-Id"
+
 type requestIDContextKey struct{}
 
 // RequestID returns the ID of the request.
@@ -34,7 +38,7 @@ func AttachRequestID(next http.Handler) http.Handler {
 		trace.SpanFromContext(ctx).
 			SetAttributes(attribute.String("request_id", rid.String()))
 
-		rw.Header().Set("X-Coder-Request-Id", ridString)
+		rw.Header().Set(RequestIDHeader, ridString)
 		next.ServeHTTP(rw, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,59 @@
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// LimitOptions configures RequestLimits. A zero value disables the
+// corresponding check.
+type LimitOptions struct {
+	// MaxURLLength is the maximum length of the request URI in bytes.
+	MaxURLLength int
+	// MaxHeaderBytes is the maximum combined size of all request header
+	// names and values, counted as they appear on the wire.
+	MaxHeaderBytes int
+}
+
+// RequestLimits rejects requests with an oversized URL (414) or headers (431)
+// using the standard JSON response. net/http rejects requests above
+// http.Server.MaxHeaderBytes itself with a plain-text body, so the limits here
+// should be lower than that to always produce a parseable error.
+func RequestLimits(opts LimitOptions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if opts.MaxURLLength > 0 && len(r.RequestURI) > opts.MaxURLLength {
+				httpapi.Write(r.Context(), rw, http.StatusRequestURITooLong, codersdk.Response{
+					Message: "Request URL is too long.",
+					Detail:  fmt.Sprintf("The URL is %d bytes, but at most %d are allowed.", len(r.RequestURI), opts.MaxURLLength),
+				})
+				return
+			}
+			if opts.MaxHeaderBytes > 0 {
+				size := headerSize(r.Header)
+				if size > opts.MaxHeaderBytes {
+					httpapi.Write(r.Context(), rw, http.StatusRequestHeaderFieldsTooLarge, codersdk.Response{
+						Message: "Request headers are too large.",
+						Detail:  fmt.Sprintf("Headers are %d bytes, but at most %d are allowed.", size, opts.MaxHeaderBytes),
+					})
+					return
+				}
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// headerSize approximates the wire size of h as "Name: value\r\n" lines.
+func headerSize(h http.Header) int {
+	var size int
+	for name, values := range h {
+		for _, value := range values {
+			size += len(name) + len(": ") + len(value) + len("\r\n")
+		}
+	}
+	return size
+}
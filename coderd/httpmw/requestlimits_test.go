@@ -0,0 +1,59 @@
+package httpmw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestRequestLimits(t *testing.T) {
+	t.Parallel()
+
+	mw := httpmw.RequestLimits(httpmw.LimitOptions{
+		MaxURLLength:   64,
+		MaxHeaderBytes: 256,
+	})
+	handler := mw(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("WithinLimits", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/users", nil)
+		handler.ServeHTTP(rw, r)
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+
+	t.Run("LongURL", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/users?q="+strings.Repeat("a", 64), nil)
+		handler.ServeHTTP(rw, r)
+		require.Equal(t, http.StatusRequestURITooLong, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.NotEmpty(t, resp.Message)
+	})
+
+	t.Run("LargeHeaders", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/api/v2/users", nil)
+		r.Header.Set("X-Large", strings.Repeat("a", 256))
+		handler.ServeHTTP(rw, r)
+		require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, rw.Code)
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.NotEmpty(t, resp.Message)
+	})
+}
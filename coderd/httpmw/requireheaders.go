@@ -0,0 +1,38 @@
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// RequireHeaders rejects requests that are missing any of the named headers,
+// or send them empty. Every missing header is listed in the validations of
+// the 400 response.
+func RequireHeaders(names ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			var missing []codersdk.ValidationError
+			for _, name := range names {
+				if strings.TrimSpace(r.Header.Get(name)) == "" {
+					missing = append(missing, codersdk.ValidationError{
+						Field:  name,
+						Detail: fmt.Sprintf("Header %q is required.", name),
+						Code:   "required",
+					})
+				}
+			}
+			if len(missing) > 0 {
+				httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+					Message:     "Missing required headers.",
+					Validations: missing,
+				})
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
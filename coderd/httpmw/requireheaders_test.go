@@ -0,0 +1,73 @@
+package httpmw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestRequireHeaders(t *testing.T) {
+	t.Parallel()
+
+	handler := httpmw.RequireHeaders("X-Coder-Session", "X-API-Version")(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		missing []string
+	}{
+		{
+			name: "AllPresent",
+			headers: map[string]string{
+				"X-Coder-Session": "abc",
+				"X-API-Version":   "2",
+			},
+		},
+		{
+			name: "OneMissing",
+			headers: map[string]string{
+				"X-Coder-Session": "abc",
+			},
+			missing: []string{"X-API-Version"},
+		},
+		{
+			name: "SeveralMissing",
+			headers: map[string]string{
+				"X-API-Version": " ",
+			},
+			missing: []string{"X-Coder-Session", "X-API-Version"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			handler.ServeHTTP(rw, r)
+
+			if len(tc.missing) == 0 {
+				require.Equal(t, http.StatusOK, rw.Code)
+				return
+			}
+			require.Equal(t, http.StatusBadRequest, rw.Code)
+			var resp codersdk.Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+			fields := make([]string, 0, len(resp.Validations))
+			for _, v := range resp.Validations {
+				fields = append(fields, v.Field)
+				require.Equal(t, "required", v.Code)
+			}
+			require.Equal(t, tc.missing, fields)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package httpmw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// HTTPSMode is how RequireHTTPS handles plain HTTP requests.
+type HTTPSMode int
+
+const (
+	// HTTPSRedirect redirects plain HTTP requests to the same URL over HTTPS.
+	HTTPSRedirect HTTPSMode = iota
+	// HTTPSReject responds to plain HTTP requests with a 400.
+	HTTPSReject
+)
+
+// RequireHTTPS ensures requests were made over HTTPS. A request is HTTPS if it
+// was served over TLS, or if trustedProxyHeader is set and the proxy reports
+// "https" in it, e.g. "X-Forwarded-Proto". The header must only be trusted
+// when a proxy always sets it, as clients can send it too.
+func RequireHTTPS(mode HTTPSMode, trustedProxyHeader string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if isHTTPS(r, trustedProxyHeader) {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			if mode == HTTPSReject {
+				httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+					Message: "HTTPS is required.",
+					Detail:  "Retry the request using an https:// URL.",
+				})
+				return
+			}
+
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				// Other methods must keep their method and body.
+				status = http.StatusPermanentRedirect
+			}
+			http.Redirect(rw, r, "https://"+r.Host+r.URL.RequestURI(), status)
+		})
+	}
+}
+
+func isHTTPS(r *http.Request, trustedProxyHeader string) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if trustedProxyHeader == "" {
+		return false
+	}
+	// Proxies chained together may each append their protocol, the first is
+	// the client's.
+	proto, _, _ := strings.Cut(r.Header.Get(trustedProxyHeader), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,77 @@
+package httpmw_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestRequireHTTPS(t *testing.T) {
+	t.Parallel()
+
+	const proxyHeader = "X-Forwarded-Proto"
+	serve := func(mode httpmw.HTTPSMode, header string, r *http.Request) *httptest.ResponseRecorder {
+		handler := httpmw.RequireHTTPS(mode, header)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, r)
+		return rw
+	}
+
+	t.Run("ForwardedHTTPS", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "http://coder.example.com/api/v2", nil)
+		r.Header.Set(proxyHeader, "https")
+		require.Equal(t, http.StatusOK, serve(httpmw.HTTPSReject, proxyHeader, r).Code)
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "https://coder.example.com/api/v2", nil)
+		r.TLS = &tls.ConnectionState{}
+		require.Equal(t, http.StatusOK, serve(httpmw.HTTPSReject, "", r).Code)
+	})
+
+	t.Run("UntrustedHeader", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "http://coder.example.com/api/v2", nil)
+		r.Header.Set(proxyHeader, "https")
+		require.Equal(t, http.StatusBadRequest, serve(httpmw.HTTPSReject, "", r).Code)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "http://coder.example.com/api/v2/users?q=1", nil)
+		r.Header.Set(proxyHeader, "http")
+		rw := serve(httpmw.HTTPSRedirect, proxyHeader, r)
+		require.Equal(t, http.StatusMovedPermanently, rw.Code)
+		require.Equal(t, "https://coder.example.com/api/v2/users?q=1", rw.Header().Get("Location"))
+	})
+
+	t.Run("RedirectPost", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("POST", "http://coder.example.com/api/v2/users", nil)
+		rw := serve(httpmw.HTTPSRedirect, proxyHeader, r)
+		require.Equal(t, http.StatusPermanentRedirect, rw.Code)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "http://coder.example.com/api/v2", nil)
+		rw := serve(httpmw.HTTPSReject, proxyHeader, r)
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Header().Get("Content-Type"), "application/json")
+
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		require.Equal(t, "HTTPS is required.", resp.Message)
+	})
+}
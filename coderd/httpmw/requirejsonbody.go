@@ -0,0 +1,65 @@
+package httpmw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// RequireJSONBody checks the body of every POST, PUT and PATCH request before
+// the handler runs: bodies must be JSON, with a 415 otherwise, and at most
+// maxBytes long, with a 413 otherwise. Requests without a body pass. The body
+// is buffered while checking and re-attached, so handlers can still Read it.
+func RequireJSONBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+			default:
+				next.ServeHTTP(rw, r)
+				return
+			}
+			if r.Body == nil || r.Body == http.NoBody || (r.ContentLength == 0 && r.Header.Get("Content-Type") == "") {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			contentType := r.Header.Get("Content-Type")
+			mediaType, _, _ := mime.ParseMediaType(contentType)
+			if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+				httpapi.Write(r.Context(), rw, http.StatusUnsupportedMediaType, codersdk.Response{
+					Message: "Request body must be JSON.",
+					Detail:  fmt.Sprintf("Unsupported content type %q.", contentType),
+				})
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxBytes))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					httpapi.Write(r.Context(), rw, http.StatusRequestEntityTooLarge, codersdk.Response{
+						Message: "Request body is too large.",
+						Detail:  fmt.Sprintf("The request body must not exceed %d bytes.", maxErr.Limit),
+					})
+					return
+				}
+				httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+					Message: "Failed to read request body.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package httpmw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestRequireJSONBody(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+	handler := httpmw.RequireJSONBody(64)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.ContentLength == 0 {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var v request
+		if !httpapi.Read(r.Context(), rw, r, &v) {
+			return
+		}
+		httpapi.Write(r.Context(), rw, http.StatusOK, v)
+	}))
+	serve := func(method, contentType, body string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		handler.ServeHTTP(rw, r)
+		return rw
+	}
+	decode := func(t *testing.T, rw *httptest.ResponseRecorder) codersdk.Response {
+		var resp codersdk.Response
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&resp))
+		return resp
+	}
+
+	t.Run("ValidPost", func(t *testing.T) {
+		t.Parallel()
+		rw := serve("POST", "application/json; charset=utf-8", `{"name":"dev"}`)
+		require.Equal(t, http.StatusOK, rw.Code)
+		var v request
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&v))
+		require.Equal(t, "dev", v.Name)
+	})
+
+	t.Run("WrongContentType", func(t *testing.T) {
+		t.Parallel()
+		rw := serve("PUT", "text/plain", `{"name":"dev"}`)
+		require.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		require.Equal(t, "Request body must be JSON.", decode(t, rw).Message)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		rw := serve("PATCH", "application/json", `{"name":"`+strings.Repeat("a", 64)+`"}`)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		require.Equal(t, "Request body is too large.", decode(t, rw).Message)
+	})
+
+	t.Run("NoBody", func(t *testing.T) {
+		t.Parallel()
+		rw := serve("POST", "", "")
+		require.Equal(t, http.StatusNoContent, rw.Code)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		t.Parallel()
+		rw := serve("GET", "text/plain", "ignored")
+		require.Equal(t, http.StatusNoContent, rw.Code)
+	})
+}
@@ -0,0 +1,54 @@
+package httpmw
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/coder/coder/v2/coderd/httpapi/routepattern"
+)
+
+// RoutePattern makes the pattern of the matched route, such as
+// "/users/{user}", available to middleware earlier in the chain through
+// RoutePatternFromContext once the handler returns. Labeling logs and metrics
+// by pattern rather than path keeps their cardinality bounded.
+//
+// Routers must report the pattern with SetRoutePattern, e.g. with
+// ServeMuxRoutePattern. Patterns matched by chi are found without it.
+func RoutePattern(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, withRoutePattern(r))
+	})
+}
+
+// withRoutePattern returns r with a route pattern holder in its context if it
+// doesn't have one already.
+func withRoutePattern(r *http.Request) *http.Request {
+	ctx := routepattern.WithHolder(r.Context())
+	if ctx == r.Context() {
+		return r
+	}
+	return r.WithContext(ctx)
+}
+
+// SetRoutePattern records the pattern of the route matching the request. It
+// does nothing if the RoutePattern middleware isn't in use.
+func SetRoutePattern(ctx context.Context, pattern string) {
+	routepattern.Set(ctx, pattern)
+}
+
+// RoutePatternFromContext returns the pattern of the route matching the
+// request, or an empty string if it isn't known (yet). Patterns set with
+// SetRoutePattern take precedence over the one matched by chi.
+func RoutePatternFromContext(ctx context.Context) string {
+	return routepattern.FromContext(ctx)
+}
+
+// ServeMuxRoutePattern serves requests with mux, reporting the matched
+// pattern, such as "GET /users/{user}", with SetRoutePattern.
+func ServeMuxRoutePattern(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		SetRoutePattern(r.Context(), pattern)
+		mux.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,55 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+)
+
+func TestRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ServeMux", func(t *testing.T) {
+		t.Parallel()
+		var inHandler, afterHandler string
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /users/{user}", func(rw http.ResponseWriter, r *http.Request) {
+			inHandler = httpmw.RoutePatternFromContext(r.Context())
+		})
+		handler := httpmw.RoutePattern(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			httpmw.ServeMuxRoutePattern(mux).ServeHTTP(rw, r)
+			afterHandler = httpmw.RoutePatternFromContext(r.Context())
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/admin", nil))
+		require.Equal(t, "GET /users/{user}", inHandler)
+		require.Equal(t, "GET /users/{user}", afterHandler)
+	})
+
+	t.Run("Chi", func(t *testing.T) {
+		t.Parallel()
+		var pattern string
+		r := chi.NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(rw, r)
+				pattern = httpmw.RoutePatternFromContext(r.Context())
+			})
+		})
+		r.Get("/workspaces/{workspace}", func(rw http.ResponseWriter, r *http.Request) {})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/workspaces/dev", nil))
+		require.Equal(t, "/workspaces/{workspace}", pattern)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "/", nil)
+		require.Empty(t, httpmw.RoutePatternFromContext(r.Context()))
+	})
+}
@@ -0,0 +1,44 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		httpmw.SecurityHeaders(httpmw.SecurityOptions{})(handler).ServeHTTP(rw, r)
+
+		require.Equal(t, "nosniff", rw.Header().Get("X-Content-Type-Options"))
+		require.Equal(t, httpmw.DefaultFrameOptions, rw.Header().Get("X-Frame-Options"))
+		require.Equal(t, httpmw.DefaultReferrerPolicy, rw.Header().Get("Referrer-Policy"))
+		require.Equal(t, httpmw.DefaultContentSecurityPolicy, rw.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("CustomCSP", func(t *testing.T) {
+		t.Parallel()
+		const csp = "default-src 'self' https://cdn.example.com"
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		httpmw.SecurityHeaders(httpmw.SecurityOptions{
+			ContentSecurityPolicy: csp,
+		})(handler).ServeHTTP(rw, r)
+
+		require.Equal(t, csp, rw.Header().Get("Content-Security-Policy"))
+		require.Equal(t, httpmw.DefaultFrameOptions, rw.Header().Get("X-Frame-Options"))
+	})
+}
@@ -0,0 +1,111 @@
+package httpmw
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type serverTimingContextKey struct{}
+
+// serverTiming accumulates durations for the Server-Timing header of a single
+// request. Phases are reported in the order they were first recorded.
+type serverTiming struct {
+	mu        sync.Mutex
+	names     []string
+	durations map[string]time.Duration
+}
+
+// RecordTiming adds d to the named phase of the request's Server-Timing
+// header, e.g. "db" or "render". Recording the same name again adds to it. It
+// does nothing if the ServerTiming middleware isn't in use.
+func RecordTiming(ctx context.Context, name string, d time.Duration) {
+	st, ok := ctx.Value(serverTimingContextKey{}).(*serverTiming)
+	if !ok {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.durations[name]; !ok {
+		st.names = append(st.names, name)
+	}
+	st.durations[name] += d
+}
+
+func (st *serverTiming) header() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	metrics := make([]string, 0, len(st.names))
+	for _, name := range st.names {
+		ms := float64(st.durations[name]) / float64(time.Millisecond)
+		metrics = append(metrics, name+";dur="+strconv.FormatFloat(ms, 'f', 1, 64))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// ServerTiming writes the phases recorded with RecordTiming to the
+// Server-Timing header, so browser dev tools can show where handler time
+// went. The header is set when the response headers are written, so only
+// phases recorded before that are included.
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		st := &serverTiming{durations: map[string]time.Duration{}}
+		tw := &serverTimingWriter{ResponseWriter: rw, timing: st}
+		next.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), serverTimingContextKey{}, st)))
+		// Handlers that never write still get a response from net/http.
+		tw.setHeader()
+	})
+}
+
+var (
+	_ http.Flusher  = (*serverTimingWriter)(nil)
+	_ http.Hijacker = (*serverTimingWriter)(nil)
+)
+
+type serverTimingWriter struct {
+	http.ResponseWriter
+	timing      *serverTiming
+	wroteHeader bool
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if header := w.timing.header(); header != "" {
+		w.Header().Set("Server-Timing", header)
+	}
+}
+
+func (w *serverTimingWriter) WriteHeader(status int) {
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *serverTimingWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *serverTimingWriter) Flush() {
+	w.setHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *serverTimingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, xerrors.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
@@ -0,0 +1,51 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/httpmw"
+)
+
+func TestServerTiming(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Phases", func(t *testing.T) {
+		t.Parallel()
+		handler := httpmw.ServerTiming(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			httpmw.RecordTiming(r.Context(), "db", 10*time.Millisecond)
+			httpmw.RecordTiming(r.Context(), "render", 4500*time.Microsecond)
+			httpmw.RecordTiming(r.Context(), "db", 2300*time.Microsecond)
+			rw.WriteHeader(http.StatusOK)
+			// Recorded after the headers were sent, so it's dropped.
+			httpmw.RecordTiming(r.Context(), "late", time.Millisecond)
+		}))
+
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, "db;dur=12.3, render;dur=4.5", rw.Header().Get("Server-Timing"))
+	})
+
+	t.Run("None", func(t *testing.T) {
+		t.Parallel()
+		handler := httpmw.ServerTiming(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, _ = rw.Write([]byte("ok"))
+		}))
+
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		require.Empty(t, rw.Header().Values("Server-Timing"))
+	})
+
+	t.Run("WithoutMiddleware", func(t *testing.T) {
+		t.Parallel()
+		r := httptest.NewRequest("GET", "/", nil)
+		require.NotPanics(t, func() {
+			httpmw.RecordTiming(r.Context(), "db", time.Millisecond)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package httpmw
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coder/coder/v2/clock"
+	"github.com/coder/coder/v2/coderd/tracing"
+)
+
+// SlowLog calls log for every request that took longer than threshold to
+// handle, with the status that was written. Fast requests aren't reported, so
+// log can be noisier than the request logger without flooding it. The path is
+// the matched route pattern if it's known, see RoutePattern.
+func SlowLog(threshold time.Duration, log func(method, path string, d time.Duration, status int)) func(http.Handler) http.Handler {
+	return SlowLogWithClock(threshold, log, clock.NewReal())
+}
+
+// SlowLogWithClock is SlowLog timing requests with clk, which tests use to
+// control how long requests take.
+func SlowLogWithClock(threshold time.Duration, log func(method, path string, d time.Duration, status int), clk clock.Clock) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := clk.Now()
+			r = withRoutePattern(r)
+
+			sw, ok := rw.(*tracing.StatusWriter)
+			if !ok {
+				sw = &tracing.StatusWriter{ResponseWriter: rw}
+			}
+			next.ServeHTTP(sw, r)
+
+			d := clk.Since(start)
+			if d <= threshold {
+				return
+			}
+			status := sw.Status
+			if status == 0 {
+				// Nothing was written, which net/http sends as a 200.
+				status = http.StatusOK
+			}
+			path := RoutePatternFromContext(r.Context())
+			if path == "" {
+				path = r.URL.Path
+			}
+			log(r.Method, path, d, status)
+		})
+	}
+}
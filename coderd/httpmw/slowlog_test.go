@@ -0,0 +1,79 @@
+package httpmw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/clock"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestSlowLog(t *testing.T) {
+	t.Parallel()
+
+	type entry struct {
+		method string
+		path   string
+		d      time.Duration
+		status int
+	}
+
+	t.Run("Fast", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+		mClock := clock.NewMock(t)
+		var logged []entry
+		handler := httpmw.SlowLogWithClock(time.Minute, func(method, path string, d time.Duration, status int) {
+			logged = append(logged, entry{method, path, d, status})
+		}, mClock)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			mClock.Advance(time.Minute).MustWait(ctx)
+			rw.WriteHeader(http.StatusOK)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+		require.Empty(t, logged)
+	})
+
+	t.Run("Slow", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+		mClock := clock.NewMock(t)
+		const delay = time.Minute + time.Millisecond
+		var logged []entry
+		handler := httpmw.SlowLogWithClock(time.Minute, func(method, path string, d time.Duration, status int) {
+			logged = append(logged, entry{method, path, d, status})
+		}, mClock)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			mClock.Advance(delay).MustWait(ctx)
+			rw.WriteHeader(http.StatusTeapot)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/slow", nil))
+		require.Len(t, logged, 1)
+		require.Equal(t, "POST", logged[0].method)
+		require.Equal(t, "/slow", logged[0].path)
+		require.Equal(t, http.StatusTeapot, logged[0].status)
+		require.Equal(t, delay, logged[0].d)
+	})
+
+	t.Run("RoutePattern", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+		mClock := clock.NewMock(t)
+		var logged []entry
+		handler := httpmw.SlowLogWithClock(0, func(method, path string, d time.Duration, status int) {
+			logged = append(logged, entry{method, path, d, status})
+		}, mClock)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			httpmw.SetRoutePattern(r.Context(), "/users/{user}")
+			mClock.Advance(time.Millisecond).MustWait(ctx)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/admin", nil))
+		require.Len(t, logged, 1)
+		require.Equal(t, "/users/{user}", logged[0].path)
+	})
+}
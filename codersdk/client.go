@@ -509,6 +509,13 @@ type Response struct {
 type ValidationError struct {
 	Field  string `json:"field" validate:"required"`
 	Detail string `json:"detail" validate:"required"`
+	// Code is a machine-readable reason for the error, such as "required" or
+	// "invalid_type". It's usually the validation tag that failed.
+	Code string `json:"code,omitempty"`
+	// ID is a stable identifier for the error, such as "username.taken", set
+	// from the errid tag of the field. Unlike Code, it doesn't depend on how
+	// the field is validated.
+	ID string `json:"id,omitempty"`
 }
 
 func (e ValidationError) Error() string {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// openapigen writes OpenAPI 3 component schemas of the request bodies that
+// coderd reads. Unlike the swagger docs, the schemas come from the validate
+// tags, so they describe what httpapi.Read actually enforces.
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	httpapi.RegisterSchema(
+		codersdk.CreateFirstUserRequest{},
+		codersdk.CreateUserRequest{},
+		codersdk.UpdateUserProfileRequest{},
+		codersdk.UpdateUserPasswordRequest{},
+		codersdk.LoginWithPasswordRequest{},
+		codersdk.CreateOrganizationRequest{},
+		codersdk.UpdateOrganizationRequest{},
+		codersdk.CreateGroupRequest{},
+		codersdk.PatchGroupRequest{},
+		codersdk.CreateTemplateRequest{},
+		codersdk.UpdateTemplateMeta{},
+		codersdk.CreateTemplateVersionRequest{},
+		codersdk.PatchTemplateVersionRequest{},
+		codersdk.CreateWorkspaceRequest{},
+		codersdk.UpdateWorkspaceRequest{},
+		codersdk.UpdateWorkspaceAutostartRequest{},
+		codersdk.UpdateWorkspaceTTLRequest{},
+		codersdk.PutExtendWorkspaceRequest{},
+		codersdk.CreateWorkspaceBuildRequest{},
+		codersdk.CreateWorkspaceProxyRequest{},
+	)
+
+	doc := httpapi.GenerateOpenAPI("Coder API request schemas", "2.0")
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshal document: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	if err != nil {
+		return xerrors.Errorf("write document: %w", err)
+	}
+	return nil
+}
@@ -1556,6 +1556,8 @@ export interface UsersRequest extends Pagination {
 export interface ValidationError {
   readonly field: string;
   readonly detail: string;
+  readonly code?: string;
+  readonly id?: string;
 }
 
 // From codersdk/organizations.go